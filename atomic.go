@@ -0,0 +1,184 @@
+package grocery
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maxWriteAttempts is how many times a write is attempted when the object
+// being written changes between its existence check and its transaction.
+const maxWriteAttempts = 10
+
+// writeScript checks whether KEYS[1] exists, and then runs the commands in
+// ARGV, which are each written as their number of arguments followed by the
+// arguments. ARGV[1] is "store" if the object must not exist, "update" if it
+// must, or anything else to skip the check, and ARGV[2] is the error returned
+// if the check fails.
+//
+// The commands may write keys other than KEYS[1], which Redis Cluster doesn't
+// allow. See UpdateOptions.Pipeline.
+var writeScript = redis.NewScript(`
+local exists = redis.call('EXISTS', KEYS[1]) == 1
+
+if (ARGV[1] == 'store' and exists) or (ARGV[1] == 'update' and not exists) then
+	return redis.error_reply(ARGV[2])
+end
+
+local i = 3
+
+while i <= #ARGV do
+	local n = tonumber(ARGV[i])
+	redis.call(unpack(ARGV, i + 1, i + n))
+	i = i + n + 1
+end
+
+return 1
+`)
+
+// stored is what's currently stored for an object that's about to be
+// written.
+type stored struct {
+	exists bool
+
+	// The IDs the object references through its backref fields, from
+	// queueBackrefReads.
+	refs map[string][]string
+
+	// The fields of the object's hash, which are only read when it's being
+	// replaced.
+	fields []string
+}
+
+// queueStoredReads adds commands to pip that read what's stored for
+// prefix:id. The returned function returns it once pip has been executed.
+func queueStoredReads(pip redis.Pipeliner, prefix, id string, typ reflect.Type, opts *UpdateOptions) func() *stored {
+	exists := pip.Exists(ctx, prefix+":"+id)
+	refs := queueBackrefReads(pip, prefix, id, typ)
+	var fields *redis.StringSliceCmd
+
+	if opts.storeReplace {
+		fields = pip.HKeys(ctx, prefix+":"+id)
+	}
+
+	return func() *stored {
+		old := &stored{exists: exists.Val() == 1, refs: refs()}
+
+		if fields != nil {
+			old.fields = fields.Val()
+		}
+
+		return old
+	}
+}
+
+// writeChecked writes the object in ptr, stored at prefix:id, with the
+// commands that queue adds to a pipeline. queue is passed what's currently
+// stored for the object.
+//
+// The object must exist if it's being updated, or not exist if it's being
+// stored, and it can't be created or deleted by anyone else between this
+// check and the write. By default, the check and the write are run with
+// WATCH and MULTI, and are retried if the object changes in between. If
+// opts.Pipeline is set, the write is added to it as a script that repeats
+// the check, since it can't be watched.
+func writeChecked(prefix, id string, ptr interface{}, typ reflect.Type, opts *UpdateOptions, queue func(pip redis.Pipeliner, old *stored) error) error {
+	key := prefix + ":" + id
+
+	if opts.Pipeline != nil {
+		return writeScripted(prefix, id, typ, opts, queue)
+	}
+
+	for attempt := 0; attempt < maxWriteAttempts; attempt++ {
+		err := C.Watch(opts.ctx, func(tx *redis.Tx) error {
+			check := tx.Pipeline()
+			read := queueStoredReads(check, prefix, id, typ, opts)
+
+			if _, err := check.Exec(opts.ctx); err != nil && err != redis.Nil {
+				return err
+			}
+
+			old := read()
+
+			if err := checkExists(prefix, id, old.exists, opts); err != nil {
+				return err
+			}
+
+			_, err := tx.TxPipelined(opts.ctx, func(pip redis.Pipeliner) error {
+				return queue(pip, old)
+			})
+
+			return err
+		}, key)
+
+		if err != redis.TxFailedErr {
+			return err
+		}
+
+		// Referenced objects weren't stored either, so store them again
+		resetCascade(ptr, opts)
+	}
+
+	return redis.TxFailedErr
+}
+
+// writeScripted adds the write described by writeChecked to opts.Pipeline as
+// a call to writeScript.
+func writeScripted(prefix, id string, typ reflect.Type, opts *UpdateOptions, queue func(pip redis.Pipeliner, old *stored) error) error {
+	key := prefix + ":" + id
+
+	// Check existence ahead of time too, so that most errors are returned
+	// here instead of when the pipeline is executed, and make sure the script
+	// is loaded before it's called by its hash
+	check := C.Pipeline()
+	read := queueStoredReads(check, prefix, id, typ, opts)
+	writeScript.Load(ctx, check)
+
+	if _, err := check.Exec(opts.ctx); err != nil && err != redis.Nil {
+		return err
+	}
+
+	old := read()
+
+	if err := checkExists(prefix, id, old.exists, opts); err != nil {
+		return err
+	}
+
+	rec, recorded := record()
+
+	if err := queue(rec, old); err != nil {
+		return err
+	}
+
+	// Fail with the same errors as checkExists
+	args := []interface{}{"update", fmt.Sprintf("%s:%s does not exist", prefix, id)}
+
+	if opts.isStore && opts.storeOverwrite {
+		args = []interface{}{"overwrite", ""}
+	} else if opts.isStore {
+		args = []interface{}{"store", fmt.Sprintf("%s:%s already exists", prefix, id)}
+	}
+
+	for _, cmd := range recorded() {
+		args = append(args, len(cmd.Args()))
+		args = append(args, cmd.Args()...)
+	}
+
+	writeScript.EvalSha(opts.ctx, opts.Pipeline, []string{key}, args...)
+	return nil
+}
+
+// resetCascade clears the IDs of the referenced objects that were given IDs
+// while storing ptr, since they weren't stored.
+func resetCascade(ptr interface{}, opts *UpdateOptions) {
+	for ref := range opts.cascaded {
+		if ref != ptr {
+			setID(reflect.ValueOf(ref).Elem(), "")
+		}
+	}
+
+	if opts.cascaded != nil {
+		opts.cascaded = make(map[interface{}]string)
+	}
+}
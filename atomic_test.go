@@ -0,0 +1,132 @@
+package grocery
+
+import (
+	"sync"
+	"testing"
+)
+
+type AtomicTestModel struct {
+	Base
+
+	Name string `grocery:"name"`
+}
+
+func TestConcurrentStore(t *testing.T) {
+	id := NewID()
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+
+	for i := range errs {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = StoreWithOptions(&AtomicTestModel{Name: "concurrent"}, &StoreOptions{ID: id})
+		}(i)
+	}
+
+	wg.Wait()
+	stored := 0
+
+	for _, err := range errs {
+		if err == nil {
+			stored++
+		}
+	}
+
+	if stored != 1 {
+		t.Errorf("TestConcurrentStore FAILED, expected 1 store to succeed but got %d", stored)
+	}
+}
+
+func TestStorePipeline(t *testing.T) {
+	id := NewID()
+	pip := C.TxPipeline()
+
+	err := StoreWithOptions(&AtomicTestModel{Name: "first"}, &StoreOptions{
+		ID:            id,
+		UpdateOptions: &UpdateOptions{Pipeline: pip},
+	})
+
+	if err != nil {
+		t.Fatalf("TestStorePipeline FAILED, got error %v", err)
+	}
+
+	// Store another object with the same ID before the pipeline is executed
+	if err := StoreWithOptions(&AtomicTestModel{Name: "second"}, &StoreOptions{ID: id}); err != nil {
+		t.Fatalf("TestStorePipeline FAILED, got error %v", err)
+	}
+
+	if _, err := pip.Exec(ctx); err == nil {
+		t.Errorf("TestStorePipeline FAILED, expected pipeline to fail")
+	}
+
+	if name := C.HGet(ctx, "atomictestmodel:"+id, "name").Val(); name != "second" {
+		t.Errorf("TestStorePipeline FAILED, expected name second but got %s", name)
+	}
+
+	// Updates through a pipeline are written once it's executed
+	pip = C.TxPipeline()
+
+	if err := UpdateWithOptions(id, &AtomicTestModel{Name: "third"}, &UpdateOptions{Pipeline: pip}); err != nil {
+		t.Fatalf("TestStorePipeline FAILED, got error %v", err)
+	}
+
+	if _, err := pip.Exec(ctx); err != nil {
+		t.Fatalf("TestStorePipeline FAILED, got error %v", err)
+	}
+
+	if name := C.HGet(ctx, "atomictestmodel:"+id, "name").Val(); name != "third" {
+		t.Errorf("TestStorePipeline FAILED, expected name third but got %s", name)
+	}
+}
+
+type ReplaceTestModel struct {
+	Base
+
+	Name  string   `grocery:"name"`
+	Price float64  `grocery:"price"`
+	Tags  []string `grocery:"tags"`
+}
+
+func TestUpsertAndReplace(t *testing.T) {
+	id := NewID()
+	key := "replacetestmodel:" + id
+
+	if err := StoreWithOptions(&ReplaceTestModel{Name: "a", Price: 1, Tags: []string{"x"}}, &StoreOptions{ID: id, Upsert: true}); err != nil {
+		t.Fatalf("TestUpsertAndReplace FAILED, got error %v", err)
+	}
+
+	// Make createdAt recognizable, so we can tell if it's rewritten
+	C.HSet(ctx, key, "createdAt", 100)
+
+	if err := StoreWithOptions(&ReplaceTestModel{Name: "b"}, &StoreOptions{ID: id, Upsert: true}); err != nil {
+		t.Fatalf("TestUpsertAndReplace FAILED, got error %v", err)
+	}
+
+	data := C.HGetAll(ctx, key).Val()
+
+	if data["createdAt"] != "100" {
+		t.Errorf("TestUpsertAndReplace FAILED, expected upsert to keep createdAt but got %s", data["createdAt"])
+	}
+
+	if data["name"] != "b" || data["price"] != "1" || C.Exists(ctx, key+":tags").Val() != 1 {
+		t.Errorf("TestUpsertAndReplace FAILED, expected upsert to update name only but got %v", data)
+	}
+
+	C.HSet(ctx, key, "stale", "field")
+
+	if err := StoreWithOptions(&ReplaceTestModel{Name: "c"}, &StoreOptions{ID: id, Replace: true}); err != nil {
+		t.Fatalf("TestUpsertAndReplace FAILED, got error %v", err)
+	}
+
+	data = C.HGetAll(ctx, key).Val()
+
+	if data["createdAt"] != "100" {
+		t.Errorf("TestUpsertAndReplace FAILED, expected replace to keep createdAt but got %s", data["createdAt"])
+	}
+
+	if _, ok := data["price"]; ok || data["stale"] != "" || data["name"] != "c" || C.Exists(ctx, key+":tags").Val() != 0 {
+		t.Errorf("TestUpsertAndReplace FAILED, expected replace to remove other fields but got %v", data)
+	}
+}
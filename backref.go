@@ -0,0 +1,192 @@
+package grocery
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// backref describes a reference field whose grocery tag has the backref
+// option. For every model referenced by such a field, grocery keeps a set of
+// the IDs of the objects referencing it, so that they can be found without
+// scanning every object. For example, a Fruit with this field:
+//
+//	Supplier *Supplier `grocery:"supplier,backref"`
+//
+// is added to the set at supplier:<supplier id>:refs:fruit when it's stored,
+// moved to another set when its supplier changes, and removed when it's
+// deleted. See LoadReferrers for loading the objects in these sets.
+//
+// The field may also declare what should happen to the object when a model it
+// references is deleted, with the ondelete option, which implies backref:
+//
+//	Supplier *Supplier `grocery:"supplier,ondelete=cascade"`
+//
+// See Delete for the available policies.
+type backref struct {
+	// The grocery key of the reference field.
+	key string
+
+	// The prefix of the referenced model.
+	refPrefix string
+
+	// Whether the field is a list of references, rather than a single one.
+	list bool
+
+	// The field's delete policy, if it has one.
+	onDelete string
+}
+
+// backrefKey returns the key of the set holding the IDs of the objects with
+// the given prefix that reference refPrefix:refID.
+func backrefKey(refPrefix, refID, prefix string) string {
+	return refPrefix + ":" + refID + ":refs:" + prefix
+}
+
+// referrersKey returns the key of the set holding the prefixes of every type
+// that has kept backrefs for references to models with the given prefix, so
+// that Delete can tell whether any of them isn't known to grocery.
+func referrersKey(refPrefix string) string {
+	return "grocery:referrers:" + refPrefix
+}
+
+// backrefReadKeys returns the keys read by queueBackrefReads for prefix:id.
+func backrefReadKeys(prefix, id string, typ reflect.Type) []string {
+	keys := []string{prefix + ":" + id}
+
+	for _, br := range schemaOf(typ).backrefs {
+		if br.list {
+			keys = append(keys, prefix+":"+id+":"+br.key)
+		}
+	}
+
+	return keys
+}
+
+// queueBackrefReads adds commands to pip that read the IDs currently stored in
+// each backref field of prefix:id. The returned function returns these IDs,
+// keyed by the field's grocery key, once pip has been executed.
+func queueBackrefReads(pip redis.Pipeliner, prefix, id string, typ reflect.Type) func() map[string][]string {
+	backrefs := schemaOf(typ).backrefs
+
+	if len(backrefs) == 0 {
+		return func() map[string][]string {
+			return nil
+		}
+	}
+
+	single := make(map[string]*redis.StringCmd)
+	lists := make(map[string]*redis.StringSliceCmd)
+
+	for _, br := range backrefs {
+		if br.list {
+			lists[br.key] = pip.LRange(ctx, prefix+":"+id+":"+br.key, 0, -1)
+		} else {
+			single[br.key] = pip.HGet(ctx, prefix+":"+id, br.key)
+		}
+	}
+
+	return func() map[string][]string {
+		refs := make(map[string][]string)
+
+		for k, cmd := range single {
+			if refID := cmd.Val(); refID != "" {
+				refs[k] = []string{refID}
+			}
+		}
+
+		for k, cmd := range lists {
+			refs[k] = cmd.Val()
+		}
+
+		return refs
+	}
+}
+
+// queueBackrefMove adds commands to pip that remove id from the backref sets
+// of the models in oldIDs that it no longer references, and add it to the
+// backref sets of the models in newIDs.
+func queueBackrefMove(pip redis.Pipeliner, refPrefix, prefix, id string, oldIDs, newIDs []string) {
+	current := make(map[string]bool)
+
+	for _, refID := range newIDs {
+		current[refID] = true
+	}
+
+	for _, refID := range oldIDs {
+		if !current[refID] {
+			pip.SRem(ctx, backrefKey(refPrefix, refID, prefix), id)
+		}
+	}
+
+	for refID := range current {
+		pip.SAdd(ctx, backrefKey(refPrefix, refID, prefix), id)
+	}
+
+	if len(current) > 0 {
+		pip.SAdd(ctx, referrersKey(refPrefix), prefix)
+	}
+}
+
+// LoadReferrers loads every object of type T that references the model with
+// the given ID through the field with grocery key k, which must have the
+// backref option. For example, to load every fruit from a supplier:
+//
+//	type Fruit struct {
+//	    grocery.Base
+//	    Supplier *Supplier `grocery:"supplier,backref"`
+//	}
+//
+//	fruits, err := db.LoadReferrers[Fruit]("supplier", supplierID)
+func LoadReferrers[T any](k, id string) ([]*T, error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	prefix := strings.ToLower(typ.Name())
+
+	op := startOperation(nil, "grocery.LoadReferrers", prefix, "")
+	referrers, err := loadReferrers[T](op.ctx, typ, prefix, k, id)
+
+	return referrers, op.end(err)
+}
+
+func loadReferrers[T any](opCtx context.Context, typ reflect.Type, prefix, k, id string) ([]*T, error) {
+	var refPrefix string
+
+	for _, br := range schemaOf(typ).backrefs {
+		if br.key == k {
+			refPrefix = br.refPrefix
+		}
+	}
+
+	if refPrefix == "" {
+		return nil, fmt.Errorf("field '%s' of %s does not keep backrefs", k, prefix)
+	}
+
+	ids, err := C.SMembers(opCtx, backrefKey(refPrefix, id, prefix)).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := LoadEach[T](ids, &LoadOptions{ctx: opCtx})
+
+	if err != nil {
+		return nil, err
+	}
+
+	referrers := make([]*T, 0, len(results))
+
+	for _, res := range results {
+		if res.Err == ErrNotFound {
+			continue
+		} else if res.Err != nil {
+			return nil, res.Err
+		}
+
+		referrers = append(referrers, res.Value)
+	}
+
+	return referrers, nil
+}
@@ -0,0 +1,99 @@
+package grocery
+
+import (
+	"testing"
+)
+
+type BackrefSupplier struct {
+	Base
+	Name string `grocery:"name"`
+}
+
+type BackrefFruit struct {
+	Base
+	Name      string             `grocery:"name"`
+	Supplier  *BackrefSupplier   `grocery:"supplier,backref"`
+	Suppliers []*BackrefSupplier `grocery:"suppliers,backref"`
+}
+
+func referrerIDs(t *testing.T, k, supplierID string) map[string]bool {
+	fruits, err := LoadReferrers[BackrefFruit](k, supplierID)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	ids := make(map[string]bool)
+
+	for _, fruit := range fruits {
+		ids[fruit.ID] = true
+	}
+
+	return ids
+}
+
+func TestBackref(t *testing.T) {
+	s1 := &BackrefSupplier{Name: "one"}
+	s1ID, _ := Store(s1)
+
+	s2 := &BackrefSupplier{Name: "two"}
+	s2ID, _ := Store(s2)
+
+	fruitID, err := Store(&BackrefFruit{Name: "mango", Supplier: s1})
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if refs := referrerIDs(t, "supplier", s1ID); len(refs) != 1 || !refs[fruitID] {
+		t.Errorf("backref store FAILED, expected %s but got %v", fruitID, refs)
+	}
+
+	// Move the fruit to the other supplier
+	if err := Update(fruitID, &BackrefFruit{Supplier: s2}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if refs := referrerIDs(t, "supplier", s1ID); len(refs) != 0 {
+		t.Errorf("backref move FAILED, expected no referrers but got %v", refs)
+	}
+
+	if refs := referrerIDs(t, "supplier", s2ID); len(refs) != 1 || !refs[fruitID] {
+		t.Errorf("backref move FAILED, expected %s but got %v", fruitID, refs)
+	}
+
+	if err := Delete(fruitID, new(BackrefFruit)); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if refs := referrerIDs(t, "supplier", s2ID); len(refs) != 0 {
+		t.Errorf("backref delete FAILED, expected no referrers but got %v", refs)
+	}
+}
+
+func TestBackrefList(t *testing.T) {
+	s1 := &BackrefSupplier{Name: "one"}
+	s1ID, _ := Store(s1)
+
+	s2 := &BackrefSupplier{Name: "two"}
+	s2ID, _ := Store(s2)
+
+	fruitID, _ := Store(&BackrefFruit{Name: "kiwi", Suppliers: []*BackrefSupplier{s1, s2}})
+
+	if refs := referrerIDs(t, "suppliers", s2ID); len(refs) != 1 || !refs[fruitID] {
+		t.Errorf("backref list FAILED, expected %s but got %v", fruitID, refs)
+	}
+
+	Update(fruitID, &BackrefFruit{Suppliers: []*BackrefSupplier{s2}})
+
+	if refs := referrerIDs(t, "suppliers", s1ID); len(refs) != 0 {
+		t.Errorf("backref list move FAILED, expected no referrers but got %v", refs)
+	}
+
+	if _, err := LoadReferrers[BackrefFruit]("name", s1ID); err == nil {
+		t.Errorf("backref FAILED, expected an error for a field without backrefs")
+	}
+}
@@ -8,6 +8,12 @@ import (
 //
 // Unlike most fields, the fields contained within Base are special and should
 // not be modified manually, as they are updated by grocery automatically.
+//
+// Base also remembers the values an object was loaded or stored with, for
+// Save. Two objects with the same fields are therefore not == unless one is a
+// copy of the other, and reflect.DeepEqual only reports them as equal if they
+// were last loaded or stored with the same values. Compare IDs or individual
+// fields instead.
 type Base struct {
 	// The timestamp at which the object was created.
 	CreatedAt time.Time `json:"createdAt" grocery:"createdAt,immutable"`
@@ -17,4 +23,9 @@ type Base struct {
 
 	// The object's unique ID.
 	ID string `json:"id,omitempty" grocery:"-"`
+
+	// The object's fields as they were last loaded or stored. See Save.
+	// Snapshots are never modified, only replaced, so copies of an object
+	// can share one.
+	snapshot *snapshot
 }
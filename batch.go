@@ -0,0 +1,283 @@
+package grocery
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Number of objects written per pipeline by StoreAll and UpdateAll, unless
+// BatchOptions.BatchSize is set.
+const defaultBatchSize = 100
+
+// BatchOptions provides options that may be passed to StoreAll and UpdateAll.
+type BatchOptions struct {
+	// BatchSize is the maximum number of objects written per pipeline. Each
+	// pipeline is executed as a transaction. Defaults to 100.
+	BatchSize int
+
+	// Set to true if you would like StoreAll to update existing objects, as
+	// with StoreOptions.Upsert.
+	Upsert bool
+
+	// Deprecated: Overwrite is the same as Upsert.
+	Overwrite bool
+
+	// All other options inherit from UpdateOptions. Pipeline is not supported,
+	// since StoreAll and UpdateAll execute their own pipelines.
+	*UpdateOptions
+}
+
+// BatchError is returned by StoreAll and UpdateAll when some of the objects
+// could not be written. It holds one error for each object, in the same order
+// as the objects passed in, which is nil for objects that were written.
+type BatchError []error
+
+func (e BatchError) Error() string {
+	failed := 0
+	var first error
+
+	for _, err := range e {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+
+			failed++
+		}
+	}
+
+	return fmt.Sprintf("%d of %d objects failed: %v", failed, len(e), first)
+}
+
+// StoreAll saves multiple objects in Redis, like calling Store for each of
+// them, but with far fewer round trips. The objects are written in
+// transactions of BatchOptions.BatchSize objects each, and as with Store, the
+// existence of the objects in each one is checked atomically with the write.
+// Each object's ID is generated with NewID and set on the object once it's
+// stored, and the IDs are returned in the same order as ptrs. If some objects
+// could not be stored, a BatchError is returned along with the IDs.
+func StoreAll[T any](ptrs []*T, opts *BatchOptions) ([]string, error) {
+	ids := make([]string, len(ptrs))
+
+	for i := range ptrs {
+		ids[i] = NewID()
+	}
+
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+
+	update := copyOptions(opts.UpdateOptions)
+	update.isStore = true
+	update.storeOverwrite = opts.Upsert || opts.Overwrite
+
+	op := startOperation(nil, "grocery.StoreAll", prefixOf(new(T)), "")
+	update.ctx = op.ctx
+
+	err := writeAll(ids, ptrs, opts.BatchSize, update)
+	op.setFields(update.written)
+
+	return ids, op.end(err)
+}
+
+// UpdateAll updates multiple objects in Redis, like calling Update for each of
+// them, but with far fewer round trips. See StoreAll for more information.
+func UpdateAll[T any](ids []string, ptrs []*T, opts *BatchOptions) error {
+	if len(ids) != len(ptrs) {
+		return errors.New("len(ids) must equal len(ptrs)")
+	}
+
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+
+	update := copyOptions(opts.UpdateOptions)
+	op := startOperation(nil, "grocery.UpdateAll", prefixOf(new(T)), "")
+	update.ctx = op.ctx
+
+	err := writeAll(ids, ptrs, opts.BatchSize, update)
+	op.setFields(update.written)
+
+	return op.end(err)
+}
+
+// writeAll writes the objects in ptrs, with the given IDs, in pipelines of
+// batchSize objects each, and counts the fields written in opts.written. opts
+// is written to, so it must not be the caller's.
+func writeAll[T any](ids []string, ptrs []*T, batchSize int, opts *UpdateOptions) error {
+	if len(ptrs) == 0 {
+		return nil
+	} else if opts.Pipeline != nil {
+		return errors.New("Pipeline is not supported when writing in batches")
+	}
+
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	if typ.Kind() != reflect.Struct {
+		return errors.New("ptrs must be struct pointers")
+	}
+
+	// Get prefix for the struct (e.g. 'item:' from Item)
+	prefix := strings.ToLower(typ.Name())
+
+	if err := register(typ); err != nil {
+		return err
+	}
+
+	errs := make(BatchError, len(ptrs))
+
+	for start := 0; start < len(ptrs); start += batchSize {
+		end := start + batchSize
+
+		if end > len(ptrs) {
+			end = len(ptrs)
+		}
+
+		var err error
+
+		for attempt := 0; attempt < maxWriteAttempts; attempt++ {
+			if err = writeChunk(prefix, ids[start:end], ptrs[start:end], typ, opts, errs[start:end]); err != redis.TxFailedErr {
+				break
+			}
+		}
+
+		if err != nil {
+			// The chunk wasn't written at all, rather than failing on one of
+			// its commands
+			for i := start; i < end; i++ {
+				if errs[i] == nil {
+					errs[i] = err
+				}
+			}
+		}
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return errs
+		}
+	}
+
+	return nil
+}
+
+// writeChunk writes the objects in ptrs, with the given IDs, in a single
+// transaction, and sets the error of each object that couldn't be written in
+// errs. As with writeChecked, the objects are watched while their existence
+// is checked, so that none of them can be created or deleted by anyone else
+// before they're written. If one of them is, redis.TxFailedErr is returned,
+// and the chunk should be written again.
+func writeChunk[T any](prefix string, ids []string, ptrs []*T, typ reflect.Type, opts *UpdateOptions, errs BatchError) error {
+	keys := make([]string, 0, len(ids))
+
+	for i := range ids {
+		if ids[i] == "" {
+			errs[i] = errors.New("ID must not be empty")
+		} else if ptrs[i] == nil {
+			errs[i] = errors.New("ptr must not be nil")
+		} else {
+			errs[i] = nil
+			keys = append(keys, prefix+":"+ids[i])
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return C.Watch(opts.ctx, func(tx *redis.Tx) error {
+		// Check the existence of every object at once, and read the
+		// references they currently keep backrefs for
+		check := tx.Pipeline()
+		reads := make([]func() *stored, len(ids))
+
+		for i := range ids {
+			if errs[i] == nil {
+				reads[i] = queueStoredReads(check, prefix, ids[i], typ, opts)
+			}
+		}
+
+		if _, err := check.Exec(opts.ctx); err != nil && err != redis.Nil {
+			return err
+		}
+
+		// Remember which commands belong to which object, so that errors can
+		// be reported for each one
+		objectCmds := make(map[int][]redis.Cmder)
+		written := make(map[int]int)
+
+		_, err := tx.TxPipelined(opts.ctx, func(pip redis.Pipeliner) error {
+			for i := range ids {
+				if errs[i] != nil {
+					continue
+				}
+
+				old := reads[i]()
+
+				if errs[i] = checkExists(prefix, ids[i], old.exists, opts); errs[i] != nil {
+					continue
+				}
+
+				// Record the object's commands first, so that none of them
+				// are sent if it can't be written
+				val := reflect.ValueOf(ptrs[i]).Elem()
+				rec, recorded := record()
+
+				opts.existed = old.exists
+				before := opts.written
+				errs[i] = queueUpdate(rec, prefix, ids[i], ptrs[i], val, typ, opts, old.refs)
+
+				// Only count the fields of objects that are written
+				written[i], opts.written = opts.written-before, before
+
+				if errs[i] != nil {
+					continue
+				}
+
+				objectCmds[i] = recorded()
+
+				for _, cmd := range objectCmds[i] {
+					pip.Process(ctx, cmd)
+				}
+			}
+
+			return nil
+		})
+
+		if err == redis.TxFailedErr {
+			return err
+		} else if err != nil && err != redis.Nil {
+			if _, ok := err.(redis.Error); !ok {
+				return err
+			}
+		}
+
+		for i, cmds := range objectCmds {
+			for _, cmd := range cmds {
+				if err := cmd.Err(); err != nil {
+					errs[i] = err
+					break
+				}
+			}
+
+			if errs[i] == nil {
+				opts.written += written[i]
+				setID(reflect.ValueOf(ptrs[i]), ids[i])
+
+				if opts.isStore {
+					keepSnapshot(reflect.ValueOf(ptrs[i]).Elem())
+				}
+			}
+		}
+
+		return nil
+	}, keys...)
+}
@@ -0,0 +1,152 @@
+package grocery
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type BatchTestModel struct {
+	Base
+	Name string `grocery:"name"`
+}
+
+func (m *BatchTestModel) PostStore(pip redis.Pipeliner) {
+	pip.SAdd(ctx, "batchTestNames", m.Name)
+}
+
+func TestStoreAll(t *testing.T) {
+	models := make([]*BatchTestModel, 250)
+
+	for i := range models {
+		models[i] = &BatchTestModel{Name: NewID()}
+	}
+
+	ids, err := StoreAll(models, &BatchOptions{BatchSize: 100})
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	loaded := make([]BatchTestModel, len(ids))
+
+	if err := LoadAll(ids, &loaded); err != nil {
+		t.Error(err)
+		return
+	}
+
+	for i := range models {
+		if models[i].ID != ids[i] {
+			t.Errorf("store all ID FAILED, expected %s but got %s", ids[i], models[i].ID)
+		}
+
+		if loaded[i].Name != models[i].Name {
+			t.Errorf("store all FAILED, expected %s but got %s", models[i].Name, loaded[i].Name)
+		}
+	}
+
+	if isMember, _ := C.SIsMember(ctx, "batchTestNames", models[0].Name).Result(); !isMember {
+		t.Errorf("store all hook FAILED, expected PostStore to be called")
+	}
+}
+
+func TestUpdateAll(t *testing.T) {
+	id, _ := Store(&BatchTestModel{Name: "before"})
+
+	err := UpdateAll([]string{id, "asdf"}, []*BatchTestModel{{Name: "after"}, {Name: "missing"}}, nil)
+
+	var batchErr BatchError
+
+	if !errors.As(err, &batchErr) {
+		t.Errorf("update all FAILED, expected a BatchError but got %v", err)
+		return
+	}
+
+	if batchErr[0] != nil || batchErr[1] == nil {
+		t.Errorf("update all FAILED, expected only the missing object to fail but got %v", batchErr)
+	}
+
+	loaded := new(BatchTestModel)
+	Load(id, loaded)
+
+	if loaded.Name != "after" {
+		t.Errorf("update all FAILED, expected %s but got %s", "after", loaded.Name)
+	}
+}
+
+func TestBatchOptionsReuse(t *testing.T) {
+	opts := &BatchOptions{UpdateOptions: &UpdateOptions{}}
+	ids, err := StoreAll([]*BatchTestModel{{Name: "before"}}, opts)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// The options must not keep any state from StoreAll
+	if err := UpdateAll(ids, []*BatchTestModel{{Name: "after"}}, opts); err != nil {
+		t.Errorf("batch options FAILED, expected update with reused options to succeed but got %v", err)
+	}
+}
+
+func TestStoreAllNonStruct(t *testing.T) {
+	value := "asdf"
+
+	if _, err := StoreAll([]*string{&value}, nil); err == nil {
+		t.Errorf("store all FAILED, expected an error for a non-struct type")
+	}
+}
+
+type RacingBatchModel struct {
+	Base
+	Name string `grocery:"name"`
+}
+
+// Deletes the object with racingBatchID once it's being updated, after its
+// existence has already been checked.
+var racingBatchID string
+
+func (m *RacingBatchModel) PreUpdate() error {
+	if racingBatchID != "" {
+		C.Del(ctx, "racingbatchmodel:"+racingBatchID)
+		racingBatchID = ""
+	}
+
+	return nil
+}
+
+func TestUpdateAllConcurrentDelete(t *testing.T) {
+	ids, err := StoreAll([]*RacingBatchModel{{Name: "deleted"}, {Name: "kept"}}, nil)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	racingBatchID = ids[0]
+	defer func() { racingBatchID = "" }()
+
+	err = UpdateAll(ids, []*RacingBatchModel{{Name: "after"}, {Name: "after"}}, nil)
+
+	var batchErr BatchError
+
+	if !errors.As(err, &batchErr) {
+		t.Errorf("update all FAILED, expected a BatchError but got %v", err)
+		return
+	}
+
+	if batchErr[0] == nil || batchErr[1] != nil {
+		t.Errorf("update all FAILED, expected only the deleted object to fail but got %v", batchErr)
+	}
+
+	// The deleted object must not be written again
+	if exists := C.Exists(ctx, "racingbatchmodel:"+ids[0]).Val(); exists != 0 {
+		t.Errorf("update all FAILED, expected deleted object to stay deleted")
+	}
+
+	if name := C.HGet(ctx, "racingbatchmodel:"+ids[1], "name").Val(); name != "after" {
+		t.Errorf("update all FAILED, expected %s but got %s", "after", name)
+	}
+}
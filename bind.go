@@ -1,248 +1,524 @@
 package grocery
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 var mapType = reflect.TypeOf(&Map{})
 var setType = reflect.TypeOf(&Set{})
 
+// loader holds the state of a single Load or LoadAll call while it walks the
+// references of the objects being loaded. Rather than fetching each reference
+// as it's found, the loader works one level of references at a time, so that
+// every level can be fetched with a constant number of pipelines.
+type loader struct {
+	opts *LoadOptions
+
+	// Context for the Redis commands run by the loader, which holds the span
+	// of the operation it's part of.
+	ctx context.Context
+
+	// Reference paths that should be expanded, or nil to expand all of them.
+	include map[string]bool
+
+	// Objects that have already been loaded or queued, keyed by prefix:id.
+	// Cyclic references are pointed back at these instances instead of being
+	// loaded again.
+	visited map[string]reflect.Value
+
+	// Objects that were referenced but don't exist in Redis, keyed by
+	// prefix:id.
+	missing map[string]bool
+
+	// Objects to fetch in the next level, keyed by prefix:id.
+	queued map[string]*loadJob
+	next   []*loadJob
+
+	// Functions to call once the current level's maps, sets and lists have
+	// been fetched.
+	callbacks []loadCallback
+
+	// Every object that was found, in the order they were found, so that
+	// their post-load hooks can be called once loading completes.
+	loaded []*loadJob
+
+	// Whether errors binding the objects passed to Load, or any of the
+	// objects they reference, should be kept on their jobs, instead of
+	// stopping the load.
+	rootErrors bool
+
+	// The number of referenced objects that were found.
+	references int
+}
+
+// loadJob is a single object being loaded by a loader.
+type loadJob struct {
+	prefix string
+	id     string
+
+	// The reference path this object was found at, and how many references
+	// deep it is from the object being loaded.
+	path  string
+	depth int
+
+	// Pointer to the struct that the object is bound to.
+	ptr reflect.Value
+
+	// The object's data, from HGetAll(prefix:id).
+	data map[string]string
+
+	// Called once the object has been found, to set the fields referencing it.
+	assign []func()
+
+	// The job of the object passed to Load that this object was first found
+	// through, which is the job itself for objects passed to Load.
+	root *loadJob
+
+	// The error that occurred while binding the object, or any of the
+	// objects first found through it, if the loader keeps errors on jobs.
+	err error
+}
+
+// loadCallback is a function to call once the maps, sets and lists of the
+// object being loaded by job have been fetched.
+type loadCallback struct {
+	job *loadJob
+	fn  func() error
+}
+
+func newLoader(opts *LoadOptions) *loader {
+	if opts == nil {
+		opts = &LoadOptions{}
+	}
+
+	l := &loader{
+		opts:    opts,
+		ctx:     ctx,
+		visited: make(map[string]reflect.Value),
+		missing: make(map[string]bool),
+		queued:  make(map[string]*loadJob),
+	}
+
+	if opts.Include != nil {
+		l.include = make(map[string]bool)
+
+		for _, path := range opts.Include {
+			// Including a nested path also includes each of its parents
+			parts := strings.Split(path, ".")
+
+			for i := range parts {
+				l.include[strings.Join(parts[:i+1], ".")] = true
+			}
+		}
+	}
+
+	return l
+}
+
+// expand returns true if the reference at path, depth levels below the object
+// being loaded, should be loaded from Redis instead of being left as a stub.
+func (l *loader) expand(path string, depth int) bool {
+	if l.opts.MaxDepth > 0 && depth > l.opts.MaxDepth {
+		return false
+	} else if l.include != nil && !l.include[path] {
+		return false
+	}
+
+	return true
+}
+
 // bind is used to load keys and values from data into ptr. Generally, data is
 // the result of C.HGetAll(prefix + ":" + id), and ptr is a pointer to a
 // struct that has been set up for usage with grocery.
 func bind(prefix, id string, data map[string]string, ptr interface{}) error {
+	return newLoader(nil).bind(prefix, id, data, ptr)
+}
+
+func (l *loader) bind(prefix, id string, data map[string]string, ptr interface{}) error {
+	job, err := l.root(prefix, id, data, ptr)
+
+	if err != nil {
+		return err
+	}
+
+	return l.load([]*loadJob{job})
+}
+
+// root returns a job for binding data to ptr, the object passed to Load.
+func (l *loader) root(prefix, id string, data map[string]string, ptr interface{}) (*loadJob, error) {
 	if ptr == nil {
-		return errors.New("ptr must not be nil")
+		return nil, errors.New("ptr must not be nil")
 	} else if reflect.TypeOf(ptr).Kind() != reflect.Ptr || reflect.TypeOf(ptr).Elem().Kind() != reflect.Struct {
-		return errors.New("ptr must be a struct pointer")
+		return nil, errors.New("ptr must be a struct pointer")
 	} else if len(data) == 0 {
-		return errors.New("data must not be empty")
+		return nil, errors.New("data must not be empty")
 	}
 
-	typ := reflect.TypeOf(ptr).Elem()
-	val := reflect.ValueOf(ptr).Elem()
-	return bindStruct(prefix, id, data, typ, val)
+	// Register the object so that references back to it reuse ptr
+	l.visited[prefix+":"+id] = reflect.ValueOf(ptr)
+	setID(reflect.ValueOf(ptr), id)
+
+	job := &loadJob{
+		prefix: prefix,
+		id:     id,
+		ptr:    reflect.ValueOf(ptr),
+		data:   data,
+	}
+
+	job.root = job
+	l.loaded = append(l.loaded, job)
+	return job, nil
 }
 
-func bindStruct(prefix, id string, data map[string]string, typ reflect.Type, val reflect.Value) error {
-	for i := 0; i < typ.NumField(); i++ {
-		typeField := typ.Field(i)
-		structField := val.Field(i)
+// load binds the data of each job, and then loads their references level by
+// level. Each level is a single pipeline, which fetches the maps, sets and
+// lists of every object bound in the previous level, along with every object
+// they reference. References stored in lists can only be queued once the list
+// itself has been fetched, so they're fetched with the following level.
+func (l *loader) load(jobs []*loadJob) error {
+	for len(jobs) > 0 || len(l.next) > 0 {
+		pip := C.Pipeline()
 
-		// Skip unexported fields
-		if !structField.CanSet() {
-			continue
+		for _, job := range jobs {
+			typ := job.ptr.Type().Elem()
+
+			if err := l.bindStruct(pip, job, job.data, typ, job.ptr.Elem()); err != nil {
+				if err := l.fail(job, err); err != nil {
+					return err
+				}
+			}
 		}
 
-		inputFieldName := typeField.Tag.Get("grocery")
+		fetching := l.next
+		cmds := make([]*redis.MapStringStringCmd, len(fetching))
 
-		if inputFieldName == "-" {
-			// Skip values that shouldn't be stored
-			continue
-		} else if structField.Kind() == reflect.Struct && typeField.Anonymous {
-			// Recurse on embedded structs
-			bindStruct(prefix, id, data, typeField.Type, structField)
-			continue
-		} else if strings.Contains(inputFieldName, ",") {
-			inputFieldName = strings.Split(inputFieldName, ",")[0]
-		} else if inputFieldName == "" {
-			// Tag was not specified, assume field name
-			if len(typeField.Name) > 1 {
-				inputFieldName = strings.ToLower(string(typeField.Name[0])) + string(typeField.Name[1:])
-			} else {
-				inputFieldName = strings.ToLower(typeField.Name)
-			}
+		for i, job := range fetching {
+			cmds[i] = pip.HGetAll(ctx, job.prefix+":"+job.id)
 		}
 
-		switch typeField.Type.Kind() {
-		case reflect.Ptr:
-			// New item to set in struct
-			res := reflect.New(typeField.Type.Elem())
+		if err := l.exec(pip, fetching); err != nil {
+			return err
+		}
 
-			if typeField.Type == mapType || (typeField.Type.Elem().Kind() == reflect.Struct && res.Elem().FieldByName("CustomMapType").IsValid()) {
-				// This is a map
-				m, err := C.HGetAll(ctx, prefix+":"+id+":"+inputFieldName).Result()
+		l.next = nil
+		l.queued = make(map[string]*loadJob)
+		jobs = l.found(fetching, cmds)
 
-				if err != nil {
+		callbacks := l.callbacks
+		l.callbacks = nil
+
+		for _, callback := range callbacks {
+			if err := callback.fn(); err != nil {
+				if err := l.fail(callback.job, err); err != nil {
 					return err
 				}
+			}
+		}
+	}
 
-				for k := range m {
-					res.MethodByName("Store").Call([]reflect.Value{
-						reflect.ValueOf(k),
-						reflect.ValueOf(m[k]),
-					})
-				}
+	// Remember what was loaded for Save, before hooks change anything
+	for _, job := range l.loaded {
+		if job.err == nil {
+			keepSnapshot(job.ptr.Elem())
+		}
+	}
 
-				if setupMethod := res.MethodByName("Setup"); setupMethod.IsValid() {
-					setupMethod.Call([]reflect.Value{})
-				}
+	// Call post-load hooks, starting with the most deeply nested objects
+	for i := len(l.loaded) - 1; i >= 0; i-- {
+		if hook, ok := l.loaded[i].ptr.Interface().(PostLoadHook); ok && l.loaded[i].err == nil {
+			hook.PostLoad()
+		}
+	}
 
-				structField.Set(res)
-			} else if typeField.Type == setType || (typeField.Type.Elem().Kind() == reflect.Struct && res.Elem().FieldByName("CustomSetType").IsValid()) {
-				// This is a set
-				s, err := C.SMembers(ctx, prefix+":"+id+":"+inputFieldName).Result()
+	return nil
+}
 
-				if err != nil {
-					return err
-				}
+// fail returns err, which occurred while binding the object loaded by job, if
+// it should stop the load. If the loader keeps errors on jobs, err is kept on
+// the job of the object passed to Load that the object was found through
+// instead, unless that job already has an error.
+func (l *loader) fail(job *loadJob, err error) error {
+	if !l.rootErrors {
+		return err
+	} else if job.root.err == nil {
+		job.root.err = err
+	}
 
-				for _, val := range s {
-					res.MethodByName("Add").Call([]reflect.Value{
-						reflect.ValueOf(val),
-					})
-				}
+	return nil
+}
 
-				if setupMethod := res.MethodByName("Setup"); setupMethod.IsValid() {
-					setupMethod.Call([]reflect.Value{})
-				}
+// exec executes the pipeline of a level. If it fetches the objects in
+// fetching, it's traced as a separate grocery.LoadReferences operation.
+func (l *loader) exec(pip redis.Pipeliner, fetching []*loadJob) error {
+	if len(fetching) == 0 {
+		_, err := pip.Exec(l.ctx)
+		return err
+	}
 
-				structField.Set(res)
-			} else if typeField.Type.Elem().Kind() == reflect.Struct {
-				if _, ok := typeField.Type.Elem().FieldByName("Base"); ok {
-					// This is a reference to a model that we should load from Redis
-					id := data[inputFieldName]
-
-					// Load data from redis
-					subPrefix := strings.ToLower(res.Type().Elem().Name())
-					dat, err := C.HGetAll(ctx, subPrefix+":"+id).Result()
-
-					if err != nil {
-						return err
-					} else if len(dat) == 0 {
-						continue
-					}
+	op := startOperation(l.ctx, "grocery.LoadReferences", "", "")
+	op.setReferences(len(fetching))
+	_, err := pip.Exec(op.ctx)
 
-					err = bindStruct(subPrefix, id, dat, res.Type().Elem(), res.Elem())
+	return op.end(err)
+}
 
-					if err != nil {
-						return err
-					}
+// found sets the data of each fetched job from the results of its HGetAll
+// command, and returns the jobs whose objects exist.
+func (l *loader) found(jobs []*loadJob, cmds []*redis.MapStringStringCmd) []*loadJob {
+	found := make([]*loadJob, 0, len(jobs))
 
-					// Set ID
-					val := res.Elem()
-					fi := reflect.Indirect(val).FieldByName("ID")
+	for i, job := range jobs {
+		job.data = cmds[i].Val()
 
-					if fi.String() != id {
-						fi.SetString(id)
-					}
+		if len(job.data) == 0 {
+			// Leave references to missing objects unset
+			l.missing[job.prefix+":"+job.id] = true
+			continue
+		}
 
-					structField.Set(res)
+		for _, assign := range job.assign {
+			assign()
+		}
 
-					// Call post-load hook
-					postLoad := res.MethodByName("PostLoad")
+		l.loaded = append(l.loaded, job)
+		l.references++
+		found = append(found, job)
+	}
 
-					if postLoad.IsValid() {
-						postLoad.Call([]reflect.Value{})
-					}
-				} else {
-					return errors.New("Can't set unsupported struct with key " + inputFieldName)
-				}
-			} else {
-				inputValue, exists := data[inputFieldName]
+	return found
+}
 
-				if !exists {
-					continue
-				}
+// after adds fn to the functions called once the current level's maps, sets
+// and lists have been fetched. job is the job of the object fn binds them to.
+func (l *loader) after(job *loadJob, fn func() error) {
+	l.callbacks = append(l.callbacks, loadCallback{job, fn})
+}
 
-				if err := setFieldWithKind(typeField.Type.Kind(), inputValue, structField); err != nil {
-					return err
-				}
-			}
-		case reflect.Slice:
-			ids, err := C.LRange(ctx, prefix+":"+id+":"+inputFieldName, 0, -1).Result()
+// ref returns a pointer to the model of type typ stored with id, which is
+// referenced at path from the object loaded by from. If the model has already been loaded or queued, the
+// existing instance is returned. If the reference shouldn't be expanded, a
+// stub with only its ID set is returned. Otherwise, the model is queued to be
+// fetched with the next level, and its job is returned too. The returned
+// pointer is invalid if the model is known to be missing.
+func (l *loader) ref(from *loadJob, typ reflect.Type, id, path string, depth int) (reflect.Value, *loadJob) {
+	prefix := schemaOf(typ).prefix
+	key := prefix + ":" + id
+
+	// Check for missing objects first, since they're still registered as
+	// visited, so that every reference to them is left unset
+	if l.missing[key] {
+		return reflect.Value{}, nil
+	} else if job, ok := l.queued[key]; ok {
+		return job.ptr, job
+	} else if existing, ok := l.visited[key]; ok {
+		return existing, nil
+	}
 
-			if err != nil {
+	ptr := reflect.New(typ)
+	setID(ptr.Elem(), id)
+
+	if !l.expand(path, depth) {
+		return ptr, nil
+	}
+
+	job := &loadJob{
+		prefix: prefix,
+		id:     id,
+		path:   path,
+		depth:  depth,
+		ptr:    ptr,
+		root:   from.root,
+	}
+
+	// Register the object before it's bound, in case it references itself
+	l.visited[key] = ptr
+	l.queued[key] = job
+	l.next = append(l.next, job)
+
+	return ptr, job
+}
+
+// bindStruct binds data to the struct val, which is part of the object being
+// loaded by job. Fields stored under their own keys, such as maps, sets and
+// lists, are fetched with pip, and are set once it has been executed.
+func (l *loader) bindStruct(pip redis.Pipeliner, job *loadJob, data map[string]string, typ reflect.Type, val reflect.Value) error {
+	prefix, id := job.prefix, job.id
+	s := schemaOf(typ)
+
+	for _, f := range s.fields {
+		structField := val.Field(f.index)
+		inputFieldName := f.key
+
+		if s.unmarshaler && f.kind != kindEmbedded {
+			// Left to UnmarshalGrocery
+			continue
+		}
+
+		switch f.kind {
+		case kindEmbedded:
+			// Recurse on embedded structs
+			if err := l.bindStruct(pip, job, data, f.typ, structField); err != nil {
 				return err
 			}
+		case kindMap:
+			// New map to set in struct
+			res := reflect.New(f.typ.Elem())
+			cmd := pip.HGetAll(ctx, prefix+":"+id+":"+inputFieldName)
 
-			arr := reflect.MakeSlice(typeField.Type, 0, len(ids))
+			l.after(job, func() error {
+				m := res.Interface().(mapStore)
 
-			for _, itemID := range ids {
-				if typeField.Type.Elem().Kind() == reflect.String {
-					// This is just a string slice
-					arr = reflect.Append(arr, reflect.ValueOf(itemID))
-				} else if _, ok := typeField.Type.Elem().Elem().FieldByName("Base"); ok {
-					// This is a reference to a model that we should load from Redis
-					ptr := reflect.New(typeField.Type.Elem().Elem())
+				for k, v := range cmd.Val() {
+					m.Store(k, v)
+				}
 
-					// Load data from redis
-					subPrefix := strings.ToLower(ptr.Type().Elem().Name())
-					dat, err := C.HGetAll(ctx, subPrefix+":"+itemID).Result()
+				if setup, ok := m.(setupper); ok {
+					setup.Setup()
+				}
 
-					if err != nil {
-						return err
-					}
+				structField.Set(res)
+				return nil
+			})
+		case kindSet:
+			// New set to set in struct
+			res := reflect.New(f.typ.Elem())
+			cmd := pip.SMembers(ctx, prefix+":"+id+":"+inputFieldName)
 
-					err = bindStruct(subPrefix, itemID, dat, ptr.Type().Elem(), ptr.Elem())
+			l.after(job, func() error {
+				set := res.Interface().(setStore)
 
-					if err != nil {
-						return err
-					}
+				for _, val := range cmd.Val() {
+					set.Add(val)
+				}
 
-					// Set ID
-					val := ptr.Elem()
-					fi := reflect.Indirect(val).FieldByName("ID")
+				if setup, ok := set.(setupper); ok {
+					setup.Setup()
+				}
 
-					if fi.String() != itemID {
-						fi.SetString(itemID)
-					}
+				structField.Set(res)
+				return nil
+			})
+		case kindRef:
+			// This is a reference to a model that we should load from Redis
+			refID, exists := data[inputFieldName]
 
-					arr = reflect.Append(arr, ptr)
-				} else {
-					return errors.New("can't set unsupported struct")
-				}
+			if !exists || refID == "" {
+				continue
 			}
 
-			structField.Set(arr)
-		case reflect.Bool:
-			if loadFunc := structField.MethodByName("Load"); loadFunc.IsValid() {
-				// Load custom boolean values
-				ret := loadFunc.Call([]reflect.Value{
-					reflect.ValueOf(id),
-					reflect.ValueOf(inputFieldName),
+			ptr, refJob := l.ref(job, f.refType, refID, joinPath(job.path, inputFieldName), job.depth+1)
+
+			if refJob != nil {
+				// Wait until the model is found before setting it
+				refJob.assign = append(refJob.assign, func() {
+					structField.Set(ptr)
 				})
+			} else if ptr.IsValid() {
+				structField.Set(ptr)
+			}
+		case kindRefList, kindStringList:
+			cmd := pip.LRange(ctx, prefix+":"+id+":"+inputFieldName, 0, -1)
+			path := joinPath(job.path, inputFieldName)
+			f := f
+
+			l.after(job, func() error {
+				ids := cmd.Val()
+				arr := reflect.MakeSlice(f.typ, 0, len(ids))
+
+				for _, itemID := range ids {
+					if f.kind == kindStringList {
+						// This is just a string slice
+						arr = reflect.Append(arr, reflect.ValueOf(itemID).Convert(f.typ.Elem()))
+						continue
+					}
 
-				val := strconv.FormatBool(ret[0].Bool())
+					ptr, _ := l.ref(job, f.refType, itemID, path, job.depth+1)
 
-				if !ret[1].IsNil() {
-					return ret[1].Interface().(error)
-				}
+					if !ptr.IsValid() {
+						// Keep missing models as stubs
+						ptr = reflect.New(f.refType)
+						setID(ptr.Elem(), itemID)
+					}
 
-				if err := setFieldWithKind(structField.Kind(), val, structField); err != nil {
-					return err
+					arr = reflect.Append(arr, ptr)
 				}
-			} else {
-				inputValue, exists := data[inputFieldName]
 
-				if !exists {
-					continue
-				}
+				structField.Set(arr)
+				return nil
+			})
+		case kindCustomBool:
+			// Load custom boolean values
+			loaded, err := structField.Addr().Interface().(customBool).Load(id, inputFieldName)
 
-				if err := setFieldWithKind(typeField.Type.Kind(), inputValue, structField); err != nil {
-					return err
-				}
+			if err != nil {
+				return err
 			}
+
+			structField.SetBool(loaded)
 		default:
+			if f.loadErr != "" && f.typ.Kind() == reflect.Slice {
+				// Only fail once the list turns out to have items
+				cmd := pip.LRange(ctx, prefix+":"+id+":"+inputFieldName, 0, -1)
+
+				l.after(job, func() error {
+					if len(cmd.Val()) > 0 {
+						return errors.New("can't set unsupported struct")
+					}
+
+					return nil
+				})
+
+				continue
+			} else if f.loadErr != "" {
+				return fmt.Errorf(f.loadErr, inputFieldName)
+			}
+
 			inputValue, exists := data[inputFieldName]
 
 			if !exists {
 				continue
 			}
 
-			if err := setFieldWithKind(typeField.Type.Kind(), inputValue, structField); err != nil {
+			if err := setFieldWithKind(f.typ.Kind(), inputValue, structField); err != nil {
 				return err
 			}
 		}
 	}
 
+	if s.unmarshaler {
+		// Let the model read its own fields
+		return val.Addr().Interface().(Unmarshaler).UnmarshalGrocery(data)
+	}
+
 	return nil
 }
 
+// joinPath appends key to a dotted reference path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// setID sets the ID of the model in val, if it isn't already set.
+func setID(val reflect.Value, id string) {
+	fi := reflect.Indirect(val).FieldByName("ID")
+
+	if fi.IsValid() && fi.String() != id {
+		fi.SetString(id)
+	}
+}
+
 func setFieldWithKind(valueKind reflect.Kind, val string, structField reflect.Value) error {
 	switch valueKind {
 	case reflect.Ptr:
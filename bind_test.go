@@ -300,3 +300,23 @@ func TestEmptyData(t *testing.T) {
 		t.Error("empty data FAILED, expecting error")
 	}
 }
+
+func BenchmarkBind(b *testing.B) {
+	data := map[string]string{
+		"es":  "asdf",
+		"s":   "asdf",
+		"i":   "-4",
+		"i64": "-4300000000",
+		"u16": "65432",
+		"f64": "-2190.3895",
+		"b":   "true",
+		"cs":  "asdf",
+		"t":   "963210120",
+	}
+
+	for i := 0; i < b.N; i++ {
+		if err := bind("", "", data, new(bindTestStruct)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package grocery
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells grocery the current time, which objects' createdAt and
+// updatedAt timestamps are set to when they're written. See SetClock.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts an ordinary function to a Clock.
+type ClockFunc func() time.Time
+
+// Now returns f().
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// The clock used by grocery, stored as a clockValue.
+var clock atomic.Value
+
+// clockValue wraps a Clock, since atomic.Value requires every value it holds
+// to have the same concrete type.
+type clockValue struct {
+	Clock
+}
+
+// SetClock replaces the clock that grocery stamps objects with, which is
+// useful for deterministic tests, or for backfilling historical records:
+//
+//	db.SetClock(db.ClockFunc(func() time.Time {
+//	    return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+//	}))
+//
+// Passing nil restores the system clock.
+func SetClock(c Clock) {
+	if c == nil {
+		c = ClockFunc(time.Now)
+	}
+
+	clock.Store(clockValue{c})
+}
+
+// now returns the current time, according to the clock set with SetClock.
+func now() time.Time {
+	if c, ok := clock.Load().(clockValue); ok {
+		return c.Now()
+	}
+
+	return time.Now()
+}
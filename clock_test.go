@@ -0,0 +1,51 @@
+package grocery
+
+import (
+	"testing"
+	"time"
+)
+
+type ClockTestModel struct {
+	Base
+
+	Name string `grocery:"name"`
+}
+
+func TestSetClock(t *testing.T) {
+	fixed := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(ClockFunc(func() time.Time {
+		return fixed
+	}))
+	defer SetClock(nil)
+
+	id, err := Store(&ClockTestModel{Name: "clock"})
+
+	if err != nil {
+		t.Fatalf("TestSetClock FAILED, got error %v", err)
+	}
+
+	m := new(ClockTestModel)
+	Load(id, m)
+
+	if !m.CreatedAt.Equal(fixed) || !m.UpdatedAt.Equal(fixed) {
+		t.Errorf("TestSetClock FAILED, expected timestamps %v but got %v and %v", fixed, m.CreatedAt, m.UpdatedAt)
+	}
+
+	// Explicit createdAt timestamps are kept, even with the system clock
+	SetClock(nil)
+	created := time.Date(2010, 6, 1, 0, 0, 0, 0, time.UTC)
+	m = &ClockTestModel{Name: "backfill"}
+	err = StoreWithOptions(m, &StoreOptions{ID: NewID(), CreatedAt: created, Load: true})
+
+	if err != nil {
+		t.Fatalf("TestSetClock FAILED, got error %v", err)
+	}
+
+	if !m.CreatedAt.Equal(created) {
+		t.Errorf("TestSetClock FAILED, expected createdAt %v but got %v", created, m.CreatedAt)
+	}
+
+	if m.UpdatedAt.Before(fixed.AddDate(1, 0, 0)) {
+		t.Errorf("TestSetClock FAILED, expected updatedAt to use the system clock but got %v", m.UpdatedAt)
+	}
+}
@@ -0,0 +1,467 @@
+// Command grocery-gen generates MarshalGrocery and UnmarshalGrocery methods
+// for grocery models, so that Store, Update and Load can write and read their
+// fields without reflection. It's meant to be run with go generate:
+//
+//	//go:generate go run github.com/nytimes/grocery/cmd/grocery-gen -type Item
+//
+// This writes item_grocery.go next to the file declaring Item. Several types
+// may be listed, separated by commas. The generated methods store fields in
+// exactly the same way grocery does, so models may be generated, or have
+// their generated methods removed, without migrating any data.
+//
+// Only fields holding strings, numbers, bools and time.Time values, or types
+// based on them, are supported. Embedded structs such as grocery.Base are
+// skipped, since grocery handles them itself, and types with any other
+// fields, such as references, maps, sets and lists, are rejected.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of type names; must be set")
+	output := flag.String("output", "", "output file name; default srcdir/<type>_grocery.go")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of grocery-gen:\n")
+		fmt.Fprintf(os.Stderr, "\tgrocery-gen -type T [-output file] [directory]\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	types := strings.Split(*typeNames, ",")
+	src, err := generate(dir, types)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "grocery-gen: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		*output = filepath.Join(dir, strings.ToLower(types[0])+"_grocery.go")
+	}
+
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "grocery-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// pkg holds the declarations of the package being generated for.
+type pkg struct {
+	name string
+
+	// Type declarations, keyed by name.
+	types map[string]*ast.TypeSpec
+
+	// The names of the methods declared on each type.
+	methods map[string]map[string]bool
+
+	// The file each type is declared in, for resolving its imports.
+	files map[string]*ast.File
+}
+
+// field is a single field of a model being generated for.
+type field struct {
+	name string
+	key  string
+
+	// The kind of value the field holds, such as "int64" or "time", and the
+	// name of its type if it's based on that kind.
+	kind     string
+	typeName string
+
+	// Whether the field may only be written by grocery itself.
+	immutable bool
+}
+
+// generate returns the formatted source of a file holding the methods of the
+// given types, which are declared in the package in dir.
+func generate(dir string, typeNames []string) ([]byte, error) {
+	p, err := parsePackage(dir, typeNames)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	imports := map[string]bool{"context": true}
+
+	for _, name := range typeNames {
+		fields, err := p.fields(name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		writeMarshal(&body, name, fields)
+		writeUnmarshal(&body, name, fields)
+
+		for _, f := range fields {
+			if f.kind != "string" {
+				imports["strconv"] = true
+			}
+
+			if f.kind == "time" {
+				imports["time"] = true
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by grocery-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\nimport (\n", p.name)
+
+	for _, path := range []string{"context", "strconv", "time"} {
+		if imports[path] {
+			fmt.Fprintf(&buf, "\t%q\n", path)
+		}
+	}
+
+	fmt.Fprintf(&buf, "\n\t\"github.com/redis/go-redis/v9\"\n)\n")
+	buf.Write(body.Bytes())
+
+	return format.Source(buf.Bytes())
+}
+
+// parsePackage parses the Go files in dir, and returns the package that
+// declares the given types.
+func parsePackage(dir string, typeNames []string) (*pkg, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for name, astPkg := range pkgs {
+		p := &pkg{
+			name:    name,
+			types:   make(map[string]*ast.TypeSpec),
+			methods: make(map[string]map[string]bool),
+			files:   make(map[string]*ast.File),
+		}
+
+		for _, file := range astPkg.Files {
+			p.add(file)
+		}
+
+		if _, ok := p.types[typeNames[0]]; ok {
+			return p, nil
+		}
+	}
+
+	return nil, fmt.Errorf("type %s not found in %s", typeNames[0], dir)
+}
+
+// add records the type and method declarations in file.
+func (p *pkg) add(file *ast.File) {
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if spec, ok := spec.(*ast.TypeSpec); ok {
+					p.types[spec.Name.Name] = spec
+					p.files[spec.Name.Name] = file
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Recv == nil || len(decl.Recv.List) == 0 {
+				continue
+			}
+
+			recv := decl.Recv.List[0].Type
+
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+
+			if ident, ok := recv.(*ast.Ident); ok {
+				if p.methods[ident.Name] == nil {
+					p.methods[ident.Name] = make(map[string]bool)
+				}
+
+				p.methods[ident.Name][decl.Name.Name] = true
+			}
+		}
+	}
+}
+
+// fields returns the stored fields of the struct type with the given name.
+func (p *pkg) fields(name string) ([]field, error) {
+	spec, ok := p.types[name]
+
+	if !ok {
+		return nil, fmt.Errorf("type %s not found", name)
+	}
+
+	st, ok := spec.Type.(*ast.StructType)
+
+	if !ok {
+		return nil, fmt.Errorf("type %s is not a struct", name)
+	}
+
+	var fields []field
+
+	for _, astField := range st.Fields.List {
+		if len(astField.Names) == 0 {
+			// Skip embedded structs, which are handled by grocery
+			continue
+		}
+
+		var tag string
+
+		if astField.Tag != nil {
+			unquoted, _ := strconv.Unquote(astField.Tag.Value)
+			tag = reflect.StructTag(unquoted).Get("grocery")
+		}
+
+		for _, ident := range astField.Names {
+			if !ident.IsExported() {
+				continue
+			}
+
+			key, opts := fieldKey(ident.Name, tag)
+
+			if key == "-" {
+				continue
+			}
+
+			kind, typeName, ok := p.kindOf(astField.Type, p.files[name], 0)
+
+			if !ok {
+				return nil, fmt.Errorf("%s.%s: unsupported field type; only strings, numbers, bools and time.Time are supported", name, ident.Name)
+			}
+
+			fields = append(fields, field{
+				name:      ident.Name,
+				key:       key,
+				kind:      kind,
+				typeName:  typeName,
+				immutable: hasOption(opts, "immutable"),
+			})
+		}
+	}
+
+	return fields, nil
+}
+
+// basicKinds are the kinds of values that are stored in an object's hash.
+var basicKinds = map[string]bool{
+	"string": true, "bool": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true,
+	"byte": true, "rune": true,
+}
+
+// kindOf returns the kind of value held by a field of type expr, declared in
+// file, along with the name of the type if it's declared in the package.
+func (p *pkg) kindOf(expr ast.Expr, file *ast.File, depth int) (kind, typeName string, ok bool) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if basicKinds[expr.Name] {
+			return basicName(expr.Name), "", true
+		}
+
+		spec, ok := p.types[expr.Name]
+
+		if !ok || depth > 8 || p.methods[expr.Name]["Load"] {
+			// Custom bools with Load methods aren't stored
+			return "", "", false
+		}
+
+		kind, _, ok := p.kindOf(spec.Type, p.files[expr.Name], depth+1)
+
+		if kind == "time" {
+			return "", "", false
+		}
+
+		return kind, expr.Name, ok
+	case *ast.SelectorExpr:
+		pkgIdent, isIdent := expr.X.(*ast.Ident)
+
+		if isIdent && expr.Sel.Name == "Time" && importPath(file, pkgIdent.Name) == "time" {
+			return "time", "", true
+		}
+	}
+
+	return "", "", false
+}
+
+// basicName returns the name of a basic type, resolving byte and rune.
+func basicName(name string) string {
+	switch name {
+	case "byte":
+		return "uint8"
+	case "rune":
+		return "int32"
+	default:
+		return name
+	}
+}
+
+// importPath returns the path of the package imported by file with the given
+// name.
+func importPath(file *ast.File, name string) string {
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+
+		if imp.Name != nil && imp.Name.Name == name {
+			return path
+		} else if imp.Name == nil && filepath.Base(path) == name {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// fieldKey returns the key a field is stored with, along with the options in
+// its grocery tag, in the same way as grocery.
+func fieldKey(name, tag string) (string, []string) {
+	parts := strings.Split(tag, ",")
+	key, opts := parts[0], parts[1:]
+
+	if key == "" {
+		key = strings.ToLower(name[:1]) + name[1:]
+	}
+
+	return key, opts
+}
+
+// hasOption returns true if option is one of the options in a grocery tag.
+func hasOption(opts []string, option string) bool {
+	for _, opt := range opts {
+		if opt == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// convert returns expr, which has type from, converted to type to.
+func convert(expr, from, to string) string {
+	if from == to {
+		return expr
+	}
+
+	return to + "(" + expr + ")"
+}
+
+// typ returns the name of the field's type.
+func (f field) typ() string {
+	if f.typeName != "" {
+		return f.typeName
+	}
+
+	return f.kind
+}
+
+func writeMarshal(w *bytes.Buffer, name string, fields []field) {
+	fmt.Fprintf(w, "\n// MarshalGrocery writes the fields of m to the hash at key.\n")
+	fmt.Fprintf(w, "func (m *%s) MarshalGrocery(ctx context.Context, pip redis.Pipeliner, key string, setZeroValues bool) error {\n", name)
+
+	for _, f := range fields {
+		if f.immutable {
+			continue
+		}
+
+		// Values are converted to the types grocery passes to HSet
+		expr := "m." + f.name
+		var nonZero, value string
+
+		switch {
+		case f.kind == "time":
+			nonZero, value = expr+" != (time.Time{})", expr+".Unix()"
+		case f.kind == "string":
+			nonZero, value = expr+` != ""`, convert(expr, f.typ(), "string")
+		case f.kind == "bool":
+			nonZero, value = expr, convert(expr, f.typ(), "bool")
+		case strings.HasPrefix(f.kind, "float"):
+			nonZero, value = expr+" != 0", convert(expr, f.typ(), "float64")
+		case strings.HasPrefix(f.kind, "uint"):
+			nonZero, value = expr+" != 0", "strconv.FormatUint("+convert(expr, f.typ(), "uint64")+", 10)"
+		default:
+			nonZero, value = expr+" != 0", convert(expr, f.typ(), "int")
+		}
+
+		fmt.Fprintf(w, "if setZeroValues || %s {\n", nonZero)
+		fmt.Fprintf(w, "pip.HSet(ctx, key, %q, %s)\n}\n\n", f.key, value)
+	}
+
+	fmt.Fprintf(w, "return nil\n}\n")
+}
+
+func writeUnmarshal(w *bytes.Buffer, name string, fields []field) {
+	fmt.Fprintf(w, "\n// UnmarshalGrocery reads the fields of m from the result of HGetAll.\n")
+	fmt.Fprintf(w, "func (m *%s) UnmarshalGrocery(data map[string]string) error {\n", name)
+
+	for _, f := range fields {
+		fmt.Fprintf(w, "if v, ok := data[%q]; ok {\n", f.key)
+
+		switch {
+		case f.kind == "time":
+			fmt.Fprintf(w, "n, _ := strconv.ParseInt(v, 10, 64)\n\n")
+			fmt.Fprintf(w, "if m.%s.Unix() != n {\nm.%s = time.Unix(n, 0)\n}\n", f.name, f.name)
+		case f.kind == "string":
+			fmt.Fprintf(w, "m.%s = %s\n", f.name, convert("v", "string", f.typ()))
+		case f.kind == "bool":
+			fmt.Fprintf(w, "if v == \"\" {\nv = \"false\"\n}\n\n")
+			fmt.Fprintf(w, "b, err := strconv.ParseBool(v)\n\nif err != nil {\nreturn err\n}\n\n")
+			fmt.Fprintf(w, "m.%s = %s\n", f.name, convert("b", "bool", f.typ()))
+		default:
+			parse, parsed, zero := "ParseInt(v, 10, %s)", "int64", "0"
+
+			if strings.HasPrefix(f.kind, "uint") {
+				parse, parsed = "ParseUint(v, 10, %s)", "uint64"
+			} else if strings.HasPrefix(f.kind, "float") {
+				parse, parsed, zero = "ParseFloat(v, %s)", "float64", "0.0"
+			}
+
+			fmt.Fprintf(w, "if v == \"\" {\nv = %q\n}\n\n", zero)
+			fmt.Fprintf(w, "n, err := strconv."+parse+"\n\nif err != nil {\nreturn err\n}\n\n", bitSize(f.kind))
+			fmt.Fprintf(w, "m.%s = %s\n", f.name, convert("n", parsed, f.typ()))
+		}
+
+		fmt.Fprintf(w, "}\n\n")
+	}
+
+	fmt.Fprintf(w, "return nil\n}\n")
+}
+
+// bitSize returns the bit size to parse a number of the given kind with.
+func bitSize(kind string) string {
+	size := strings.TrimLeft(kind, "uintfloat")
+
+	if size == "" {
+		return "0"
+	}
+
+	return size
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSource = `package models
+
+import (
+	"time"
+
+	"github.com/nytimes/grocery"
+)
+
+type Flag bool
+
+func (Flag) Load(id, key string) (bool, error) {
+	return true, nil
+}
+
+type Item struct {
+	grocery.Base
+
+	Name    string    ` + "`grocery:\"name\"`" + `
+	Created time.Time ` + "`grocery:\"created,immutable\"`" + `
+	hidden  string
+}
+
+type Basket struct {
+	grocery.Base
+
+	Item *Item
+}
+
+type Flagged struct {
+	grocery.Base
+
+	Flag Flag
+}
+`
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(testSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := generate(dir, []string{"Item"})
+
+	if err != nil {
+		t.Fatalf("TestGenerate FAILED, got error %v", err)
+	}
+
+	out := string(src)
+
+	if !strings.Contains(out, "package models") || !strings.Contains(out, `pip.HSet(ctx, key, "name", m.Name)`) {
+		t.Errorf("TestGenerate FAILED, expected name to be marshaled but got %s", out)
+	}
+
+	if strings.Contains(out, `pip.HSet(ctx, key, "created"`) || !strings.Contains(out, `data["created"]`) {
+		t.Errorf("TestGenerate FAILED, expected immutable field to only be unmarshaled but got %s", out)
+	}
+
+	if strings.Contains(out, "hidden") {
+		t.Errorf("TestGenerate FAILED, expected unexported field to be skipped but got %s", out)
+	}
+
+	for _, typ := range []string{"Basket", "Flagged"} {
+		if _, err := generate(dir, []string{typ}); err == nil {
+			t.Errorf("TestGenerate FAILED, expected %s to be rejected", typ)
+		}
+	}
+}
@@ -2,7 +2,11 @@ package grocery
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -11,6 +15,32 @@ import (
 const (
 	// Redis channel to send a test message on during initialization.
 	firstMessageChannel = "grocery_hello_world"
+
+	// How long Init waits for Redis, unless InitOptions.Timeout is set.
+	defaultInitTimeout = 10 * time.Second
+
+	// How long Subscribe waits for Redis to confirm new subscriptions.
+	subscribeTimeout = 5 * time.Second
+
+	// How long the pub/sub connection may go without receiving anything
+	// before it's pinged, and then before it's considered lost.
+	healthCheckInterval = 30 * time.Second
+
+	// Delays between attempts to reconnect the pub/sub connection, which
+	// double after each failed attempt.
+	minReconnectDelay = 100 * time.Millisecond
+	maxReconnectDelay = 10 * time.Second
+)
+
+// Connection states reported to the functions added with OnConnectionState.
+const (
+	// The pub/sub connection was lost. Messages published until it's
+	// reconnected aren't received.
+	StateDisconnected = "disconnected"
+
+	// The pub/sub connection was re-established, and every channel and
+	// pattern with listeners was subscribed to again.
+	StateReconnected = "reconnected"
 )
 
 var (
@@ -22,100 +52,821 @@ var (
 	ctx = context.Background()
 
 	// Callback functions that listen for events published to Redis.
-	handlers = make(map[string][]func(string, []byte))
+	handlers = make(map[string][]*listener)
+
+	// Callback functions that listen for events published to any channel
+	// matching a pattern, keyed by patterns such as 'item:*'.
+	patternHandlers = make(map[string][]*listener)
 
 	// Handler synchronization.
 	handlersMux sync.RWMutex
 
 	// Persistent pubsub connection that waits for published events.
 	psc *redis.PubSub
+
+	// Whether psc is started by the first listener, rather than by Init. See
+	// InitOptions.LazyPubSub.
+	lazyPubSub bool
+
+	// Options for the dispatchers of pub/sub clients, from InitOptions, and
+	// the dispatcher of psc.
+	dispatchOptions *DispatchOptions
+	dispatch        *dispatcher
+
+	// Channels that are closed once Redis confirms subscriptions, keyed by
+	// the kind of subscription and the channel or pattern (e.g.
+	// 'subscribe:item:asdf').
+	pending = make(map[string][]chan struct{})
+
+	// Pending subscription synchronization.
+	pendingMux sync.Mutex
+
+	// Turns to send subscription changes to Redis, in the order they were
+	// reserved by sendLater. queuedSends is guarded by handlersMux, and
+	// sentSends by sendMux.
+	queuedSends uint64
+	sentSends   uint64
+	sendMux     sync.Mutex
+	sendDone    = sync.NewCond(&sendMux)
+
+	// Callback functions that listen for changes to the state of the pub/sub
+	// connection, guarded by handlersMux.
+	stateHandlers []func(ConnectionEvent)
+
+	// Counters reported by PubSubStatus, updated atomically.
+	disconnected   int32
+	reconnects     int64
+	possiblyMissed int64
 )
 
+// ConnectionEvent is passed to the functions added with OnConnectionState
+// when the state of the pub/sub connection changes.
+type ConnectionEvent struct {
+	// StateDisconnected or StateReconnected.
+	State string
+
+	// The error that caused the connection to be lost, for
+	// StateDisconnected.
+	Err error
+
+	// The number of attempts it took to reconnect, for StateReconnected.
+	Attempts int
+}
+
+// PubSubStats describes the pub/sub connection. See PubSubStatus.
+type PubSubStats struct {
+	// Whether the connection is currently established.
+	Connected bool
+
+	// The number of times the connection was re-established after being
+	// lost.
+	Reconnects int64
+
+	// The number of channels and patterns that had listeners while the
+	// connection was lost, counted once for each time it was lost. Messages
+	// published to them in the meantime were never received, so listeners
+	// may need to reload whatever they're watching.
+	PossiblyMissed int64
+
+	// The number of received messages waiting to be handled.
+	QueueDepth int
+
+	// The number of messages dropped because their queue was full, and the
+	// number of handlers that panicked or timed out. See DispatchOptions.
+	Dropped  int64
+	Panics   int64
+	Timeouts int64
+}
+
+// InitOptions provides options that may be passed to InitWithOptions if the
+// default behavior of Init needs to be changed.
+type InitOptions struct {
+	// Context may be set to cancel initialization. Defaults to
+	// context.Background().
+	Context context.Context
+
+	// Timeout is the longest InitWithOptions waits for the pub/sub client to
+	// start listening. Defaults to 10 seconds. Redis commands are bounded by
+	// the timeouts in redis.Options instead, unless ContextTimeoutEnabled is
+	// set.
+	Timeout time.Duration
+
+	// LazyPubSub should be set to true if you wouldn't like the pub/sub client
+	// to be started until something subscribes to a channel, for example if
+	// pub/sub is disabled on your Redis server and you don't use Subscribe
+	// or Watch.
+	LazyPubSub bool
+
+	// Dispatch changes how received messages are handed to listeners. By
+	// default, messages on each channel are handled in order by one of 8
+	// workers. See DispatchOptions.
+	Dispatch *DispatchOptions
+
+	// Telemetry enables OpenTelemetry tracing and metrics for grocery's
+	// operations, once Redis responds to the new client. See
+	// TelemetryOptions.
+	Telemetry *TelemetryOptions
+}
+
+// ErrPubSubUnavailable is returned by Init when the pub/sub client can't
+// confirm that it's receiving messages in time. This usually means that
+// pub/sub is disabled on the Redis server, or that PUBLISH is denied by its
+// ACLs. See InitOptions.LazyPubSub.
+var ErrPubSubUnavailable = errors.New("pub/sub is unavailable")
+
 // Init initializes the Redis client and additionally starts a pub/sub client.
+// The pub/sub client is only subscribed to the channels that have listeners.
+// Init fails if Redis doesn't respond, or if the pub/sub client doesn't start
+// listening within 10 seconds.
+//
+// If Init was called before, the previous client is closed once Redis
+// responds to the new one, and commands still running on it fail with
+// redis.ErrClosed. If Redis doesn't respond, the previous client is kept.
 func Init(config *redis.Options) error {
-	C = redis.NewClient(config)
+	return InitWithOptions(config, &InitOptions{})
+}
+
+// InitWithOptions initializes the Redis client, like Init, but with options.
+func InitWithOptions(config *redis.Options, opts *InitOptions) error {
+	if opts == nil {
+		opts = &InitOptions{}
+	}
+
+	initCtx, timeout := opts.Context, opts.Timeout
+
+	if initCtx == nil {
+		initCtx = context.Background()
+	}
+
+	if timeout <= 0 {
+		timeout = defaultInitTimeout
+	}
 
-	if _, err := C.Ping(ctx).Result(); err != nil {
+	initCtx, cancel := context.WithTimeout(initCtx, timeout)
+	defer cancel()
+
+	t, err := newTelemetry(opts.Telemetry)
+
+	if err != nil {
+		return err
+	}
+
+	client := redis.NewClient(config)
+
+	if _, err := client.Ping(initCtx).Result(); err != nil {
+		// Keep the previous client, if any
+		client.Close()
 		return err
 	}
 
-	// Wait until PSubscribe receives its first message to return
-	firstMessageSignal := make(chan bool)
-	go listenForUpdates(firstMessageSignal)
+	// Subscribe to the channels of any listeners added before Init, along
+	// with a channel that confirms the connection is receiving messages. The
+	// previous pub/sub client, if any, stops listening once it's closed. The
+	// client is replaced while holding the lock, along with the pub/sub
+	// client it creates
+	handlersMux.Lock()
+	previous := C
+	C = client
+	instruments.Store(t)
+
+	if psc != nil {
+		// Closing waits for any subscriptions that are still being sent
+		go psc.Close()
+		psc = nil
+	}
+
+	if previous != nil {
+		// Close the previous client once its pub/sub client can no longer
+		// take its closed connections for a lost connection
+		previous.Close()
+	}
+
+	lazyPubSub = opts.LazyPubSub
+	dispatchOptions = opts.Dispatch
+
+	if lazyPubSub && len(handlers) == 0 && len(patternHandlers) == 0 {
+		// Started by addListener instead
+		handlersMux.Unlock()
+		return nil
+	}
+
+	sub, firstMessage := startPubSub(client, firstMessageChannel)
+	handlersMux.Unlock()
 
 	ticker := time.NewTicker(time.Millisecond * 10)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-firstMessageSignal:
-			// Wait until we can confirm listenForUpdates is working before returning
-			close(firstMessageSignal)
-			return nil
+		case <-firstMessage:
+			// Wait until we can confirm listenForUpdates is working before
+			// returning, after which the channel is no longer needed
+			return sub.Unsubscribe(ctx, firstMessageChannel)
+		case <-initCtx.Done():
+			stopPubSub(sub)
+			return fmt.Errorf("%w: no message received on %s: %v", ErrPubSubUnavailable, firstMessageChannel, initCtx.Err())
 		case <-ticker.C:
 			// Repeatedly send messages while we wait for listenForUpdates to
 			// start listening
-			C.Publish(ctx, firstMessageChannel, "")
+			if err := client.Publish(initCtx, firstMessageChannel, "").Err(); err != nil && initCtx.Err() == nil {
+				stopPubSub(sub)
+				return fmt.Errorf("%w: %v", ErrPubSubUnavailable, err)
+			}
 		}
 	}
 }
 
-func listenForUpdates(firstMessageSignal chan bool) {
-	receivedFirstMessage := false
-	psc = C.PSubscribe(ctx, "*")
-	ch := psc.Channel()
+// startPubSub creates the pub/sub client from client, subscribed to every
+// channel and pattern with listeners along with channels, and starts listening
+// to it. The returned channel is closed once a message is received on
+// firstMessageChannel. The caller must hold handlersMux.
+func startPubSub(client *redis.Client, channels ...string) (*redis.PubSub, <-chan struct{}) {
+	listened, patterns := listenedKeys()
+	channels = append(channels, listened...)
+
+	sub := client.Subscribe(ctx)
+	psc = sub
+	dispatch = newDispatcher(dispatchOptions)
+	atomic.StoreInt32(&disconnected, 0)
+	firstMessage := make(chan struct{})
+	d := dispatch
+	send := subscribe(sub, channels, patterns)
+
+	go func() {
+		send()
+		listenForUpdates(client, sub, d, firstMessage)
+	}()
+
+	return sub, firstMessage
+}
+
+// stopPubSub closes the pub/sub client sub, which stops listening to it.
+func stopPubSub(sub *redis.PubSub) {
+	handlersMux.Lock()
+	defer handlersMux.Unlock()
+
+	if psc == sub {
+		psc = nil
+	}
+
+	// Closing waits for any subscriptions that are still being sent
+	go sub.Close()
+}
+
+// listenForUpdates receives messages from the pub/sub client sub and hands
+// them to d until it's closed, reconnecting through client whenever its
+// connection is lost. firstMessage is closed once a message is received on
+// firstMessageChannel.
+func listenForUpdates(client *redis.Client, sub *redis.PubSub, d *dispatcher, firstMessage chan struct{}) {
+	defer d.stop()
+	pinged := false
+
+	for {
+		received, err := sub.ReceiveTimeout(ctx, healthCheckInterval)
+
+		if err == redis.ErrClosed {
+			// Closed by Init
+			return
+		} else if netErr, ok := err.(net.Error); ok && netErr.Timeout() && !pinged {
+			// Make sure the connection is still alive after a quiet period
+			pinged = true
+
+			if err = sub.Ping(ctx); err == nil {
+				continue
+			}
+		}
+
+		if err != nil {
+			if sub = reconnect(client, sub, err); sub == nil {
+				return
+			}
 
-	for msg := range ch {
-		if !receivedFirstMessage && msg.Channel == firstMessageChannel {
-			// Send signal on first message to confirm PSubscribe is ready
-			firstMessageSignal <- true
-			receivedFirstMessage = true
 			continue
 		}
 
-		handlersMux.RLock()
-		handlers, ok := handlers[msg.Channel]
-		handlersMux.RUnlock()
+		pinged = false
+
+		if confirmation, ok := received.(*redis.Subscription); ok {
+			confirmSubscription(confirmation.Kind, confirmation.Channel)
+			continue
+		}
+
+		msg, ok := received.(*redis.Message)
 
 		if !ok {
-			// Received message for a channel that nobody is subscribed to
 			continue
+		} else if msg.Channel == firstMessageChannel {
+			if firstMessage != nil {
+				// Signal the first message to confirm the subscription is
+				// ready
+				close(firstMessage)
+				firstMessage = nil
+			}
+
+			continue
+		}
+
+		handlersMux.RLock()
+		listeners := handlers[msg.Channel]
+
+		if msg.Pattern != "" {
+			listeners = patternHandlers[msg.Pattern]
 		}
 
-		for _, handler := range handlers {
-			handler(msg.Channel, []byte(msg.Payload))
+		handlersMux.RUnlock()
+
+		if len(listeners) > 0 {
+			d.dispatch(msg.Channel, []byte(msg.Payload), listeners)
 		}
 	}
 }
 
-// Subscribe adds a new listener function to a channel in our pub/sub
-// connection. For example, if you want to listen to events on the 'reset'
-// channel, and then publish a test event, you might do the following:
+// reconnect replaces the pub/sub client old, whose connection was lost
+// because of cause, with a new one created from client that's subscribed to
+// every channel and pattern with listeners. It retries with increasing delays
+// until it succeeds, and returns nil if old was replaced or closed by Init
+// meanwhile.
+func reconnect(client *redis.Client, old *redis.PubSub, cause error) *redis.PubSub {
+	handlersMux.RLock()
+	replaced := psc != old
+	missed := len(handlers) + len(patternHandlers)
+	handlersMux.RUnlock()
+
+	if replaced {
+		// Closed by Init, which may have caused the error
+		return nil
+	}
+
+	atomic.StoreInt32(&disconnected, 1)
+	atomic.AddInt64(&possiblyMissed, int64(missed))
+	notifyState(ConnectionEvent{State: StateDisconnected, Err: cause})
+
+	delay := minReconnectDelay
+
+	for attempt := 1; ; attempt++ {
+		time.Sleep(delay)
+
+		if sub, err := resubscribe(client, old); err == nil {
+			atomic.StoreInt32(&disconnected, 0)
+			atomic.AddInt64(&reconnects, 1)
+			notifyState(ConnectionEvent{State: StateReconnected, Attempts: attempt})
+			return sub
+		} else if err == redis.ErrClosed {
+			return nil
+		}
+
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// resubscribe replaces the pub/sub client old with a new one created from
+// client that's subscribed to every channel and pattern with listeners, or
+// returns redis.ErrClosed if old was already replaced.
+func resubscribe(client *redis.Client, old *redis.PubSub) (*redis.PubSub, error) {
+	handlersMux.RLock()
+	replaced := psc != old
+	channels, patterns := listenedKeys()
+	handlersMux.RUnlock()
+
+	if replaced {
+		return nil, redis.ErrClosed
+	}
+
+	// Subscribe without holding the lock, so that listeners can still be
+	// added and messages dispatched if Redis is slow to respond. Ping first,
+	// since nothing is sent if there aren't any subscriptions
+	sub := client.Subscribe(ctx)
+	err := sub.Ping(ctx)
+
+	if err == nil && len(channels) > 0 {
+		err = sub.Subscribe(ctx, channels...)
+	}
+
+	if err == nil && len(patterns) > 0 {
+		err = sub.PSubscribe(ctx, patterns...)
+	}
+
+	if err != nil {
+		sub.Close()
+
+		if err == redis.ErrClosed {
+			// Only old may be reported as closed
+			err = net.ErrClosed
+		}
+
+		return nil, err
+	}
+
+	handlersMux.Lock()
+
+	if psc != old {
+		// Replaced by Init or Close in the meantime
+		handlersMux.Unlock()
+		go sub.Close()
+		return nil, redis.ErrClosed
+	}
+
+	// Listeners added or removed in the meantime were subscribed through
+	// old, so make the same changes to sub
+	currentChannels, currentPatterns := listenedKeys()
+	added := subscribe(sub, missingFrom(currentChannels, channels), missingFrom(currentPatterns, patterns))
+	removed := unsubscribe(sub, missingFrom(channels, currentChannels), missingFrom(patterns, currentPatterns))
+	psc = sub
+	handlersMux.Unlock()
+
+	// Closing waits for any subscriptions that are still being sent
+	go old.Close()
+	added()
+	removed()
+
+	return sub, nil
+}
+
+// missingFrom returns the keys that are in keys but not in other.
+func missingFrom(keys, other []string) []string {
+	known := make(map[string]bool, len(other))
+
+	for _, key := range other {
+		known[key] = true
+	}
+
+	var missing []string
+
+	for _, key := range keys {
+		if !known[key] {
+			missing = append(missing, key)
+		}
+	}
+
+	return missing
+}
+
+// notifyState calls the functions added with OnConnectionState.
+func notifyState(event ConnectionEvent) {
+	handlersMux.RLock()
+	handlers := stateHandlers
+	handlersMux.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// OnConnectionState adds a function that's called whenever the pub/sub
+// connection is lost or re-established. Grocery reconnects on its own, with
+// increasing delays between attempts, and subscribes to every channel with
+// listeners again, but messages published while the connection was lost are
+// never received:
 //
-//	db.Subscribe([]string{"reset"}, func(channel string, payload []byte) {
-//	    fmt.Println("receiving data from " + channel)
+//	db.OnConnectionState(func(event db.ConnectionEvent) {
+//	    if event.State == db.StateReconnected {
+//	        // Reload anything that may have changed
+//	    }
 //	})
-//
-//	db.C.Publish("reset", "payload")
-func Subscribe(channels []string, handler func(string, []byte)) {
+func OnConnectionState(handler func(ConnectionEvent)) {
 	handlersMux.Lock()
 	defer handlersMux.Unlock()
 
+	stateHandlers = append(stateHandlers, handler)
+}
+
+// PubSubStatus returns the current state of the pub/sub connection and its
+// queues of received messages, along with counters of how often it was lost
+// and how often messages couldn't be handled.
+func PubSubStatus() PubSubStats {
+	handlersMux.RLock()
+	defer handlersMux.RUnlock()
+
+	stats := PubSubStats{
+		Connected:      psc != nil && atomic.LoadInt32(&disconnected) == 0,
+		Reconnects:     atomic.LoadInt64(&reconnects),
+		PossiblyMissed: atomic.LoadInt64(&possiblyMissed),
+		Dropped:        atomic.LoadInt64(&dropped),
+		Panics:         atomic.LoadInt64(&panics),
+		Timeouts:       atomic.LoadInt64(&timeouts),
+	}
+
+	if psc != nil {
+		stats.QueueDepth = dispatch.depth()
+	}
+
+	return stats
+}
+
+// listenedKeys returns the channels and patterns that have listeners. The
+// caller must hold handlersMux.
+func listenedKeys() (channels, patterns []string) {
+	for channel := range handlers {
+		channels = append(channels, channel)
+	}
+
+	for pattern := range patternHandlers {
+		patterns = append(patterns, pattern)
+	}
+
+	return channels, patterns
+}
+
+// listener is a callback function added with Subscribe, SubscribePattern or
+// Watch, which can be removed on its own with removeListener.
+type listener struct {
+	handler func(string, []byte)
+}
+
+// addListener adds handler to each of channels, and to each of patterns,
+// which are matched by Redis as with PSUBSCRIBE. It returns once Redis has
+// confirmed the subscriptions to any channels or patterns that didn't have
+// listeners yet.
+func addListener(channels, patterns []string, handler func(string, []byte)) *listener {
+	l := &listener{handler}
+	var newChannels, newPatterns []string
+
+	handlersMux.Lock()
+
 	for _, channel := range channels {
-		if _, ok := handlers[channel]; !ok {
-			handlers[channel] = []func(string, []byte){}
+		if len(handlers[channel]) == 0 {
+			newChannels = append(newChannels, channel)
+		}
+
+		handlers[channel] = append(handlers[channel], l)
+	}
+
+	for _, pattern := range patterns {
+		if len(patternHandlers[pattern]) == 0 {
+			newPatterns = append(newPatterns, pattern)
+		}
+
+		patternHandlers[pattern] = append(patternHandlers[pattern], l)
+	}
+
+	var confirmed []chan struct{}
+	send := func() {}
+
+	if psc == nil && lazyPubSub {
+		// Start the pub/sub client, which subscribes to every channel and
+		// pattern with listeners
+		newChannels, newPatterns = listenedKeys()
+		confirmed = append(awaitSubscriptions("subscribe", newChannels), awaitSubscriptions("psubscribe", newPatterns)...)
+		startPubSub(C)
+	} else if psc != nil {
+		confirmed = append(awaitSubscriptions("subscribe", newChannels), awaitSubscriptions("psubscribe", newPatterns)...)
+		send = subscribe(psc, newChannels, newPatterns)
+	}
+
+	handlersMux.Unlock()
+	send()
+
+	// Wait without the lock, since listenForUpdates needs it to dispatch
+	// the messages received before the confirmations
+	timeout := time.NewTimer(subscribeTimeout)
+	defer timeout.Stop()
+
+	for _, ch := range confirmed {
+		select {
+		case <-ch:
+		case <-timeout.C:
+			return l
+		}
+	}
+
+	return l
+}
+
+// removeListener removes l from every channel and pattern it was added to,
+// and unsubscribes from any that no longer have listeners.
+func removeListener(l *listener) {
+	handlersMux.Lock()
+	send := unsubscribe(psc, removeFrom(handlers, l), removeFrom(patternHandlers, l))
+	handlersMux.Unlock()
+
+	send()
+}
+
+// removeFrom removes l from the listeners in m, and returns the keys that no
+// longer have any.
+func removeFrom(m map[string][]*listener, l *listener) []string {
+	var emptied []string
+
+	for key, listeners := range m {
+		kept := make([]*listener, 0, len(listeners))
+
+		for _, other := range listeners {
+			if other != l {
+				kept = append(kept, other)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(m, key)
+			emptied = append(emptied, key)
+		} else if len(kept) < len(listeners) {
+			m[key] = kept
+		}
+	}
+
+	return emptied
+}
+
+// subscribe reserves a turn with sendLater to subscribe the pub/sub client sub
+// to channels and patterns, and returns the function that sends the
+// subscriptions. The caller must hold handlersMux.
+func subscribe(sub *redis.PubSub, channels, patterns []string) func() {
+	if sub == nil || len(channels)+len(patterns) == 0 {
+		return func() {}
+	}
+
+	return sendLater(func() {
+		if len(channels) > 0 {
+			sub.Subscribe(ctx, channels...)
 		}
 
-		handlers[channel] = append(handlers[channel], handler)
+		if len(patterns) > 0 {
+			sub.PSubscribe(ctx, patterns...)
+		}
+	})
+}
+
+// unsubscribe reserves a turn with sendLater to unsubscribe the pub/sub client
+// sub from channels and patterns, and returns the function that sends the
+// unsubscriptions. The caller must hold handlersMux.
+func unsubscribe(sub *redis.PubSub, channels, patterns []string) func() {
+	if sub == nil || len(channels)+len(patterns) == 0 {
+		return func() {}
 	}
+
+	return sendLater(func() {
+		if len(channels) > 0 {
+			sub.Unsubscribe(ctx, channels...)
+		}
+
+		if len(patterns) > 0 {
+			sub.PUnsubscribe(ctx, patterns...)
+		}
+	})
 }
 
-// Unsubscribe removes all listeners waiting on any channel in channels.
+// sendLater reserves the next turn to send subscription changes to Redis, and
+// returns a function that waits for the changes reserved before it to be sent,
+// and then sends its own with send. The caller must hold handlersMux, so that
+// the changes reach Redis in the same order as the listener changes they're
+// for, and must call the returned function once it releases the lock, since
+// go-redis ignores context deadlines by default, and may wait as long as its
+// dial and read timeouts while it sends them.
+func sendLater(send func()) func() {
+	queuedSends++
+	turn := queuedSends
+
+	return func() {
+		sendMux.Lock()
+
+		for sentSends != turn-1 {
+			sendDone.Wait()
+		}
+
+		sendMux.Unlock()
+		send()
+
+		sendMux.Lock()
+		sentSends = turn
+		sendDone.Broadcast()
+		sendMux.Unlock()
+	}
+}
+
+// awaitSubscriptions returns a channel for each of names, which is closed
+// once Redis confirms the subscription of the given kind ("subscribe" or
+// "psubscribe") to it.
+func awaitSubscriptions(kind string, names []string) []chan struct{} {
+	pendingMux.Lock()
+	defer pendingMux.Unlock()
+
+	confirmed := make([]chan struct{}, len(names))
+
+	for i, name := range names {
+		confirmed[i] = make(chan struct{})
+		pending[kind+":"+name] = append(pending[kind+":"+name], confirmed[i])
+	}
+
+	return confirmed
+}
+
+// confirmSubscription closes the channels waiting for the subscription of the
+// given kind to name.
+func confirmSubscription(kind, name string) {
+	pendingMux.Lock()
+	defer pendingMux.Unlock()
+
+	for _, ch := range pending[kind+":"+name] {
+		close(ch)
+	}
+
+	delete(pending, kind+":"+name)
+}
+
+// Close stops the pub/sub client, waits for the handlers of messages that
+// were already received to return, including handlers that timed out, and
+// then closes the Redis client. Redis
+// commands, including those run by grocery, fail with redis.ErrClosed once
+// Close returns. If ctx is done before the handlers return, the client is
+// closed anyway and ctx's error is returned.
+//
+// Init may be called again once Close returns. Listeners that were added
+// before Close are kept, and subscribed to again by Init.
+func Close(ctx context.Context) error {
+	handlersMux.Lock()
+	sub, d, client := psc, dispatch, C
+	psc, dispatch = nil, nil
+
+	// Listeners added from now on are only subscribed to by Init
+	lazyPubSub = false
+	handlersMux.Unlock()
+
+	var err error
+
+	if sub != nil {
+		// Closing waits for any subscriptions that are still being sent, and
+		// the listener stops the dispatcher once it's closed
+		go sub.Close()
+
+		select {
+		case <-d.done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	if client != nil {
+		if closeErr := client.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+// Subscription is a handler added with Subscribe or SubscribePattern.
+type Subscription struct {
+	l *listener
+}
+
+// Close removes the subscription's handler, and unsubscribes the pub/sub
+// client from its channels or patterns if nothing else listens to them.
+// Other handlers on the same channels keep receiving messages. Close may be
+// called more than once.
+func (s *Subscription) Close() {
+	removeListener(s.l)
+}
+
+// Subscribe adds a new listener function to a channel in our pub/sub
+// connection, and subscribes to the channel in Redis if it didn't have any
+// listeners yet. For example, if you want to listen to events on the 'reset'
+// channel, and then publish a test event, you might do the following:
+//
+//	sub := db.Subscribe([]string{"reset"}, func(channel string, payload []byte) {
+//	    fmt.Println("receiving data from " + channel)
+//	})
+//
+//	db.C.Publish("reset", "payload")
+//
+// The returned Subscription may be closed to remove only this listener.
+func Subscribe(channels []string, handler func(string, []byte)) *Subscription {
+	return &Subscription{addListener(channels, nil, handler)}
+}
+
+// SubscribePattern adds a new listener function to every channel matching
+// any of patterns, which are glob-style patterns matched by Redis as with
+// PSUBSCRIBE. For example, to listen to the notifications of every Item:
+//
+//	sub := db.SubscribePattern([]string{"item:*"}, func(channel string, payload []byte) {
+//	    fmt.Println("item updated at " + channel)
+//	})
+//
+// A message published to a channel that matches several patterns, or a
+// pattern and a channel subscribed to with Subscribe, is received once for
+// each of them.
+func SubscribePattern(patterns []string, handler func(string, []byte)) *Subscription {
+	return &Subscription{addListener(nil, patterns, handler)}
+}
+
+// Unsubscribe removes all listeners waiting on any channel in channels, and
+// unsubscribes the pub/sub client from them. Use Subscription.Close to
+// remove a single listener instead.
 func Unsubscribe(channels []string) {
 	handlersMux.Lock()
-	defer handlersMux.Unlock()
+
+	var emptied []string
 
 	for _, channel := range channels {
-		delete(handlers, channel)
+		if _, ok := handlers[channel]; ok {
+			delete(handlers, channel)
+			emptied = append(emptied, channel)
+		}
 	}
+
+	send := unsubscribe(psc, emptied, nil)
+	handlersMux.Unlock()
+
+	send()
 }
@@ -0,0 +1,570 @@
+package grocery
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestSubscribe(t *testing.T) {
+	channel := "connectortest:" + NewID()
+	received := make(chan string, 1)
+
+	Subscribe([]string{channel}, func(channel string, payload []byte) {
+		received <- string(payload)
+	})
+
+	if channels, err := C.PubSubChannels(ctx, "connectortest:*").Result(); err != nil {
+		t.Fatal(err)
+	} else if len(channels) != 1 || channels[0] != channel {
+		t.Errorf("TestSubscribe FAILED, expected to be subscribed to %s only, got %v", channel, channels)
+	}
+
+	if patterns, err := C.PubSubNumPat(ctx).Result(); err != nil {
+		t.Fatal(err)
+	} else if patterns != 0 {
+		t.Errorf("TestSubscribe FAILED, expected no pattern subscriptions, got %d", patterns)
+	}
+
+	C.Publish(ctx, channel, "payload")
+
+	select {
+	case payload := <-received:
+		if payload != "payload" {
+			t.Errorf("TestSubscribe FAILED, expected payload, got %s", payload)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("TestSubscribe FAILED, expected a message")
+	}
+
+	Unsubscribe([]string{channel})
+
+	// Unsubscribing isn't confirmed, so wait for Redis to process it
+	deadline := time.Now().Add(time.Second)
+
+	for time.Now().Before(deadline) {
+		if channels := C.PubSubChannels(ctx, "connectortest:*").Val(); len(channels) == 0 {
+			return
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	t.Errorf("TestSubscribe FAILED, expected to be unsubscribed from %s", channel)
+}
+
+// reinit closes the client, and initializes it again for the other tests.
+func reinit() {
+	Close(ctx)
+	Init(&redis.Options{Addr: "localhost:6379"})
+	C.AddHook(testHook{})
+}
+
+// testProxy forwards connections to Redis, so that tests can drop them.
+type testProxy struct {
+	ln    net.Listener
+	mux   sync.Mutex
+	conns []net.Conn
+
+	// If set, only this many connections are forwarded, and any others are
+	// left hanging.
+	limit int
+
+	// If set, called before each connection is forwarded.
+	onConnect func()
+}
+
+func startTestProxy(t *testing.T, addr string, limit int) *testProxy {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &testProxy{ln: ln, limit: limit}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+
+			if err != nil {
+				return
+			}
+
+			p.mux.Lock()
+			hang := p.limit > 0 && len(p.conns) >= p.limit*2
+			onConnect := p.onConnect
+			p.mux.Unlock()
+
+			if hang {
+				continue
+			} else if onConnect != nil {
+				onConnect()
+			}
+
+			upstream, err := net.Dial("tcp", addr)
+
+			if err != nil {
+				conn.Close()
+				continue
+			}
+
+			p.mux.Lock()
+			p.conns = append(p.conns, conn, upstream)
+			p.mux.Unlock()
+
+			go io.Copy(upstream, conn)
+			go io.Copy(conn, upstream)
+		}
+	}()
+
+	return p
+}
+
+// drop closes every connection made through the proxy.
+func (p *testProxy) drop() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	for _, conn := range p.conns {
+		conn.Close()
+	}
+
+	p.conns = nil
+}
+
+func TestReconnect(t *testing.T) {
+	proxy := startTestProxy(t, "localhost:6379", 0)
+	defer proxy.ln.Close()
+
+	defer reinit()
+
+	if err := Init(&redis.Options{Addr: proxy.ln.Addr().String()}); err != nil {
+		t.Fatal(err)
+	}
+
+	states := make(chan ConnectionEvent, 10)
+
+	OnConnectionState(func(event ConnectionEvent) {
+		select {
+		case states <- event:
+		default:
+		}
+	})
+
+	channel := "connectortest:" + NewID()
+	received := make(chan string, 1)
+
+	Subscribe([]string{channel}, func(channel string, payload []byte) {
+		received <- string(payload)
+	})
+
+	defer Unsubscribe([]string{channel})
+
+	before := PubSubStatus()
+	proxy.drop()
+
+	for _, state := range []string{StateDisconnected, StateReconnected} {
+		select {
+		case event := <-states:
+			if event.State != state {
+				t.Fatalf("TestReconnect FAILED, expected %s, got %+v", state, event)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("TestReconnect FAILED, expected %s", state)
+		}
+	}
+
+	if status := PubSubStatus(); !status.Connected || status.Reconnects != before.Reconnects+1 {
+		t.Errorf("TestReconnect FAILED, expected one more reconnect, got %+v", status)
+	} else if status.PossiblyMissed <= before.PossiblyMissed {
+		t.Errorf("TestReconnect FAILED, expected possibly missed messages to be counted, got %+v", status)
+	}
+
+	C.Publish(ctx, channel, "payload")
+
+	select {
+	case payload := <-received:
+		if payload != "payload" {
+			t.Errorf("TestReconnect FAILED, expected payload, got %s", payload)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("TestReconnect FAILED, expected a message after reconnecting")
+	}
+}
+
+func TestReconnectAddListener(t *testing.T) {
+	proxy := startTestProxy(t, "localhost:6379", 0)
+	defer proxy.ln.Close()
+
+	defer reinit()
+
+	if err := Init(&redis.Options{Addr: proxy.ln.Addr().String()}); err != nil {
+		t.Fatal(err)
+	}
+
+	states := make(chan ConnectionEvent, 10)
+
+	OnConnectionState(func(event ConnectionEvent) {
+		select {
+		case states <- event:
+		default:
+		}
+	})
+
+	first := "connectortest:" + NewID()
+	Subscribe([]string{first}, func(string, []byte) {})
+	defer Unsubscribe([]string{first})
+
+	late := "connectortest:" + NewID()
+	received := make(chan string, 1)
+	var armed, added int32
+
+	// Add a listener while the new pub/sub connection is being made, once
+	// the old one is known to be lost, which must not have to wait for it
+	proxy.mux.Lock()
+
+	proxy.onConnect = func() {
+		if atomic.LoadInt32(&disconnected) == 0 || !atomic.CompareAndSwapInt32(&armed, 1, 0) {
+			return
+		}
+
+		go Subscribe([]string{late}, func(channel string, payload []byte) {
+			received <- string(payload)
+		})
+
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond * 10) {
+			if !handlersMux.TryRLock() {
+				continue
+			}
+
+			n := len(handlers[late])
+			handlersMux.RUnlock()
+
+			if n > 0 {
+				atomic.StoreInt32(&added, 1)
+				return
+			}
+		}
+	}
+
+	proxy.mux.Unlock()
+	defer Unsubscribe([]string{late})
+
+	atomic.StoreInt32(&armed, 1)
+	proxy.drop()
+
+	for reconnected := false; !reconnected; {
+		select {
+		case event := <-states:
+			reconnected = event.State == StateReconnected
+		case <-time.After(time.Second * 5):
+			t.Fatalf("TestReconnectAddListener FAILED, expected %s", StateReconnected)
+		}
+	}
+
+	if atomic.LoadInt32(&added) == 0 {
+		t.Errorf("TestReconnectAddListener FAILED, expected listener to be added while reconnecting")
+	}
+
+	// The listener was added after the keys to subscribe to were read, so
+	// it's only subscribed to by comparing them again
+	C.Publish(ctx, late, "payload")
+
+	select {
+	case payload := <-received:
+		if payload != "payload" {
+			t.Errorf("TestReconnectAddListener FAILED, expected payload, got %s", payload)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("TestReconnectAddListener FAILED, expected a message after reconnecting")
+	}
+}
+
+func TestInitClosesPrevious(t *testing.T) {
+	defer reinit()
+
+	previous := C
+
+	// The previous client is kept if Redis doesn't respond to the new one
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln.Close()
+
+	if err := Init(&redis.Options{Addr: ln.Addr().String(), MaxRetries: -1}); err == nil {
+		t.Fatal("TestInitClosesPrevious FAILED, expected Init to fail")
+	} else if C != previous || C.Ping(ctx).Err() != nil {
+		t.Errorf("TestInitClosesPrevious FAILED, expected previous client to be kept")
+	}
+
+	if err := Init(&redis.Options{Addr: "localhost:6379"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := previous.Ping(ctx).Err(); err != redis.ErrClosed {
+		t.Errorf("TestInitClosesPrevious FAILED, expected previous client to be closed, got %v", err)
+	}
+}
+
+func TestInitNilOptions(t *testing.T) {
+	defer reinit()
+
+	if err := InitWithOptions(&redis.Options{Addr: "localhost:6379"}, nil); err != nil {
+		t.Errorf("TestInitNilOptions FAILED, got error %v", err)
+	}
+}
+
+func TestInitTimeout(t *testing.T) {
+	// The pub/sub client's connection is never forwarded
+	proxy := startTestProxy(t, "localhost:6379", 1)
+	defer proxy.ln.Close()
+
+	defer reinit()
+
+	err := InitWithOptions(&redis.Options{Addr: proxy.ln.Addr().String(), PoolSize: 1}, &InitOptions{
+		Timeout: time.Millisecond * 200,
+	})
+
+	if !errors.Is(err, ErrPubSubUnavailable) {
+		t.Errorf("TestInitTimeout FAILED, expected ErrPubSubUnavailable, got %v", err)
+	} else if PubSubStatus().Connected {
+		t.Errorf("TestInitTimeout FAILED, expected pub/sub client to be stopped")
+	}
+}
+
+func TestLazyPubSub(t *testing.T) {
+	defer reinit()
+
+	// Listeners left by other tests would start the pub/sub client
+	handlersMux.Lock()
+	saved, savedPatterns := handlers, patternHandlers
+	handlers, patternHandlers = make(map[string][]*listener), make(map[string][]*listener)
+	handlersMux.Unlock()
+
+	defer func() {
+		handlersMux.Lock()
+		handlers, patternHandlers = saved, savedPatterns
+		handlersMux.Unlock()
+	}()
+
+	if err := InitWithOptions(&redis.Options{Addr: "localhost:6379"}, &InitOptions{LazyPubSub: true}); err != nil {
+		t.Fatal(err)
+	} else if PubSubStatus().Connected {
+		t.Errorf("TestLazyPubSub FAILED, expected pub/sub client not to be started")
+	}
+
+	channel := "connectortest:" + NewID()
+	received := make(chan string, 1)
+
+	Subscribe([]string{channel}, func(channel string, payload []byte) {
+		received <- string(payload)
+	})
+
+	defer Unsubscribe([]string{channel})
+
+	if !PubSubStatus().Connected {
+		t.Errorf("TestLazyPubSub FAILED, expected pub/sub client to be started by Subscribe")
+	}
+
+	C.Publish(ctx, channel, "payload")
+
+	select {
+	case payload := <-received:
+		if payload != "payload" {
+			t.Errorf("TestLazyPubSub FAILED, expected payload, got %s", payload)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("TestLazyPubSub FAILED, expected a message")
+	}
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	channel := "connectortest:" + NewID()
+	first := make(chan string, 1)
+	second := make(chan string, 1)
+
+	sub := Subscribe([]string{channel}, func(channel string, payload []byte) {
+		first <- string(payload)
+	})
+
+	other := Subscribe([]string{channel}, func(channel string, payload []byte) {
+		second <- string(payload)
+	})
+
+	defer other.Close()
+
+	sub.Close()
+	sub.Close()
+	C.Publish(ctx, channel, "payload")
+
+	select {
+	case <-second:
+	case <-time.After(time.Second):
+		t.Errorf("TestSubscriptionClose FAILED, expected the other subscription to receive messages")
+	}
+
+	select {
+	case <-first:
+		t.Errorf("TestSubscriptionClose FAILED, expected closed subscription not to receive messages")
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func TestSendLater(t *testing.T) {
+	release := make(chan struct{})
+	sent := make(chan int, 2)
+
+	handlersMux.Lock()
+	first := sendLater(func() {
+		<-release
+		sent <- 1
+	})
+	second := sendLater(func() {
+		sent <- 2
+	})
+	handlersMux.Unlock()
+
+	go second()
+	go first()
+
+	// Sending doesn't hold the lock
+	locked := false
+
+	for deadline := time.Now().Add(time.Second); !locked && time.Now().Before(deadline); time.Sleep(time.Millisecond * 10) {
+		if locked = handlersMux.TryLock(); locked {
+			handlersMux.Unlock()
+		}
+	}
+
+	if !locked {
+		t.Errorf("TestSendLater FAILED, expected the lock to be released while sending")
+	}
+
+	// Later turns wait for the earlier ones to be sent
+	select {
+	case <-sent:
+		t.Errorf("TestSendLater FAILED, expected second turn to wait for the first")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	close(release)
+
+	for _, turn := range []int{1, 2} {
+		select {
+		case got := <-sent:
+			if got != turn {
+				t.Errorf("TestSendLater FAILED, expected turn %d, got %d", turn, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("TestSendLater FAILED, expected turn %d to be sent", turn)
+		}
+	}
+}
+
+func TestSubscribePattern(t *testing.T) {
+	prefix := "connectortest" + NewID()
+	received := make(chan string, 2)
+
+	sub := SubscribePattern([]string{prefix + ":*"}, func(channel string, payload []byte) {
+		received <- channel
+	})
+
+	C.Publish(ctx, prefix+":a", "")
+	C.Publish(ctx, "other"+prefix+":b", "")
+	C.Publish(ctx, prefix+":c", "")
+
+	// Messages on different channels may be handled in any order
+	channels := map[string]bool{prefix + ":a": true, prefix + ":c": true}
+
+	for range []string{"a", "c"} {
+		select {
+		case got := <-received:
+			if !channels[got] {
+				t.Errorf("TestSubscribePattern FAILED, expected message on %s:a or %s:c, got %s", prefix, prefix, got)
+			}
+
+			delete(channels, got)
+		case <-time.After(time.Second):
+			t.Errorf("TestSubscribePattern FAILED, expected messages on %v", channels)
+		}
+	}
+
+	sub.Close()
+
+	// Unsubscribing isn't confirmed, so wait for Redis to process it
+	deadline := time.Now().Add(time.Second)
+
+	for time.Now().Before(deadline) {
+		if C.PubSubNumPat(ctx).Val() == 0 {
+			return
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	t.Errorf("TestSubscribePattern FAILED, expected pattern to be unsubscribed")
+}
+
+func TestClose(t *testing.T) {
+	defer reinit()
+
+	channel := "connectortest:" + NewID()
+	started := make(chan bool)
+	handled := make(chan string, 2)
+
+	sub := Subscribe([]string{channel}, func(channel string, payload []byte) {
+		if string(payload) == "slow" {
+			started <- true
+			time.Sleep(time.Millisecond * 100)
+		}
+
+		handled <- string(payload)
+	})
+
+	defer sub.Close()
+
+	C.Publish(ctx, channel, "slow")
+	<-started
+
+	if err := Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-handled:
+	default:
+		t.Errorf("TestClose FAILED, expected Close to wait for running handlers")
+	}
+
+	if err := C.Ping(ctx).Err(); err != redis.ErrClosed {
+		t.Errorf("TestClose FAILED, expected client to be closed, got %v", err)
+	} else if PubSubStatus().Connected {
+		t.Errorf("TestClose FAILED, expected pub/sub client to be closed")
+	}
+
+	// Listeners are subscribed to again
+	if err := Init(&redis.Options{Addr: "localhost:6379"}); err != nil {
+		t.Fatal(err)
+	}
+
+	C.Publish(ctx, channel, "payload")
+
+	select {
+	case payload := <-handled:
+		if payload != "payload" {
+			t.Errorf("TestClose FAILED, expected payload, got %s", payload)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("TestClose FAILED, expected a message after initializing again")
+	}
+}
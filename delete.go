@@ -0,0 +1,361 @@
+package grocery
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Policies for references to deleted objects, which may be declared on
+// reference fields with the ondelete option. See Delete.
+const (
+	// Deleting the referenced object fails while this reference to it exists.
+	OnDeleteRestrict = "restrict"
+
+	// The object holding this reference is deleted along with the referenced
+	// object.
+	OnDeleteCascade = "cascade"
+
+	// This reference is removed from the object holding it.
+	OnDeleteSetNull = "setnull"
+)
+
+// DeleteOptions provides options that may be passed to DeleteWithOptions.
+type DeleteOptions struct {
+	// Notify should be set to true if you would like a Notification to be
+	// published for the deleted object, any objects deleted along with it,
+	// and any objects its references were removed from.
+	Notify bool
+}
+
+// Delete removes the object with the given ID from Redis, along with the keys
+// holding its maps, sets and lists. As with Load, ptr is a pointer to a struct
+// of the object's type, which is only used to determine its prefix and fields:
+//
+//	itemID := "asdf"
+//	db.Delete(itemID, new(Item))
+//
+// The object is also removed from the backref sets of the models it
+// references. See LoadReferrers for more information.
+//
+// References to the object from other models are handled according to the
+// ondelete option of the fields holding them, if they have one:
+//
+//	type Fruit struct {
+//	    grocery.Base
+//
+//	    // Deleting a supplier also deletes its fruits
+//	    Supplier *Supplier `grocery:"supplier,ondelete=cascade"`
+//
+//	    // Deleting a store fails while it still has fruits
+//	    Store *Store `grocery:"store,ondelete=restrict"`
+//
+//	    // Deleting a basket removes it from its fruits
+//	    Baskets []*Basket `grocery:"baskets,ondelete=setnull"`
+//	}
+//
+// All of these changes are made in a single transaction, so either every
+// object is updated or deleted, or none are. The objects and backref sets they
+// depend on are watched while the transaction is planned, and it's planned
+// again if any of them change, such as when a new referencing object is
+// stored. Types that keep backrefs must be known to grocery before deleting
+// the models they reference, see Register. Delete fails if any objects of a
+// type that isn't known reference the object.
+func Delete(id string, ptr interface{}) error {
+	return DeleteWithOptions(id, ptr, &DeleteOptions{})
+}
+
+// DeleteWithOptions deletes an object, like Delete, but with options.
+func DeleteWithOptions(id string, ptr interface{}, opts *DeleteOptions) error {
+	_, typ, err := structOf(ptr)
+
+	if err != nil {
+		return err
+	}
+
+	if err := register(typ); err != nil {
+		return err
+	}
+
+	// Get prefix for the struct (e.g. 'item:' from Item)
+	prefix := strings.ToLower(typ.Name())
+
+	op := startOperation(nil, "grocery.Delete", prefix, id)
+	return op.end(deleteInternal(op.ctx, prefix, id, ptr, typ, opts))
+}
+
+// deleteInternal deletes prefix:id, watching every key its deletion depends
+// on, so that it's planned again if any of them change before it's executed.
+func deleteInternal(opCtx context.Context, prefix, id string, ptr interface{}, typ reflect.Type, opts *DeleteOptions) error {
+	if reflect.TypeOf(ptr).Kind() != reflect.Ptr {
+		// Hooks need a pointer to call
+		ptr = reflect.New(typ).Interface()
+	}
+
+	for attempt := 0; attempt < maxWriteAttempts; attempt++ {
+		err := C.Watch(opCtx, func(tx *redis.Tx) error {
+			check := tx.Pipeline()
+			exists := check.Exists(ctx, prefix+":"+id)
+			oldRefs := queueBackrefReads(check, prefix, id, typ)
+
+			if _, err := check.Exec(opCtx); err != nil && err != redis.Nil {
+				return err
+			} else if exists.Val() == 0 {
+				return fmt.Errorf("%s:%s does not exist", prefix, id)
+			}
+
+			d := &deletion{
+				ctx:       opCtx,
+				tx:        tx,
+				pip:       tx.TxPipeline(),
+				deleted:   make(map[string]bool),
+				referrers: make(map[string][]reflect.Type),
+				notify:    opts.Notify,
+			}
+
+			if err := d.plan(prefix, id, ptr, oldRefs()); err != nil {
+				return err
+			}
+
+			_, err := d.pip.Exec(opCtx)
+			return err
+		}, backrefReadKeys(prefix, id, typ)...)
+
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+
+	return redis.TxFailedErr
+}
+
+// deletion queues the commands needed to delete an object, along with the
+// changes required by the delete policies of the references to it.
+type deletion struct {
+	// Context of the Delete operation, for the commands that are run.
+	ctx context.Context
+
+	// The transaction the deletion is executed in. Every key that's read
+	// while planning it is watched first.
+	tx  *redis.Tx
+	pip redis.Pipeliner
+
+	// Objects that are being deleted, keyed by prefix:id.
+	deleted map[string]bool
+
+	// The types that keep backrefs for each prefix, from referrerTypes.
+	referrers map[string][]reflect.Type
+
+	// Whether notifications are published for the objects that are deleted
+	// or updated.
+	notify bool
+}
+
+// referrer is an object that references one being deleted.
+type referrer struct {
+	id string
+
+	// The IDs the object references through its backref fields.
+	refs map[string][]string
+}
+
+// plan queues the commands needed to delete prefix:id, and to apply the
+// delete policies of every reference to it. ptr is a pointer to a struct of
+// the object's type, which its hooks are called on, and oldRefs holds the IDs
+// the object references through its backref fields.
+func (d *deletion) plan(prefix, id string, ptr interface{}, oldRefs map[string][]string) error {
+	if d.deleted[prefix+":"+id] {
+		return nil
+	}
+
+	d.deleted[prefix+":"+id] = true
+	setID(reflect.ValueOf(ptr), id)
+
+	if hook, ok := ptr.(PreDeleteHook); ok {
+		if err := hook.PreDelete(); err != nil {
+			return err
+		}
+	}
+
+	queueDelete(d.pip, prefix, id, reflect.TypeOf(ptr).Elem(), oldRefs)
+
+	if hook, ok := ptr.(PostDeleteHook); ok {
+		hook.PostDelete(d.pip)
+	}
+
+	if d.notify {
+		queueNotify(d.pip, prefix, id, EventDeleted, nil)
+	}
+
+	refTypes, err := d.referrerTypes(prefix, id)
+
+	if err != nil {
+		return err
+	}
+
+	for _, refTyp := range refTypes {
+		refPrefix := strings.ToLower(refTyp.Name())
+		referrers, err := d.loadReferrers(prefix, id, refTyp)
+
+		if err != nil {
+			return err
+		}
+
+		// The set of referrers won't be needed once this object is deleted
+		d.pip.Del(ctx, backrefKey(prefix, id, refPrefix))
+
+		for _, br := range schemaOf(refTyp).backrefs {
+			if br.refPrefix != prefix || br.onDelete == "" {
+				continue
+			}
+
+			for _, r := range referrers {
+				if !contains(r.refs[br.key], id) || d.deleted[refPrefix+":"+r.id] {
+					continue
+				}
+
+				switch br.onDelete {
+				case OnDeleteRestrict:
+					return fmt.Errorf("can't delete %s:%s, it is referenced by %s:%s", prefix, id, refPrefix, r.id)
+				case OnDeleteCascade:
+					if err := d.plan(refPrefix, r.id, reflect.New(refTyp).Interface(), r.refs); err != nil {
+						return err
+					}
+				case OnDeleteSetNull:
+					if br.list {
+						d.pip.LRem(ctx, refPrefix+":"+r.id+":"+br.key, 0, id)
+					} else {
+						d.pip.HDel(ctx, refPrefix+":"+r.id, br.key)
+					}
+
+					if d.notify {
+						queueNotify(d.pip, refPrefix, r.id, EventUpdated, &changes{fields: []string{br.key}})
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// referrerTypes returns the types that keep backrefs for references to
+// prefix:id. Their delete policies can only be applied if they're known to
+// grocery, so it fails if a type that isn't known has kept backrefs for
+// prefix:id, according to the set at referrersKey(prefix).
+func (d *deletion) referrerTypes(prefix, id string) ([]reflect.Type, error) {
+	if types, ok := d.referrers[prefix]; ok {
+		return types, nil
+	}
+
+	if err := d.tx.Watch(d.ctx, referrersKey(prefix)).Err(); err != nil {
+		return nil, err
+	}
+
+	names, err := d.tx.SMembers(d.ctx, referrersKey(prefix)).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	types := referrersOf(prefix)
+	known := make(map[string]bool)
+
+	for _, typ := range types {
+		known[strings.ToLower(typ.Name())] = true
+	}
+
+	for _, name := range names {
+		if known[name] {
+			continue
+		}
+
+		key := backrefKey(prefix, id, name)
+
+		if err := d.tx.Watch(d.ctx, key).Err(); err != nil {
+			return nil, err
+		} else if n, err := d.tx.Exists(d.ctx, key).Result(); err != nil {
+			return nil, err
+		} else if n > 0 {
+			return nil, fmt.Errorf("can't delete %s:%s, it is referenced by %s, which must be registered to apply its delete policies", prefix, id, name)
+		}
+	}
+
+	d.referrers[prefix] = types
+	return types, nil
+}
+
+// loadReferrers returns the objects of type refTyp in the backref set of
+// prefix:id, along with the IDs they reference through their backref fields.
+func (d *deletion) loadReferrers(prefix, id string, refTyp reflect.Type) ([]referrer, error) {
+	refPrefix := strings.ToLower(refTyp.Name())
+	key := backrefKey(prefix, id, refPrefix)
+
+	if err := d.tx.Watch(d.ctx, key).Err(); err != nil {
+		return nil, err
+	}
+
+	ids, err := d.tx.SMembers(d.ctx, key).Result()
+
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	var watched []string
+
+	for _, refID := range ids {
+		watched = append(watched, backrefReadKeys(refPrefix, refID, refTyp)...)
+	}
+
+	if err := d.tx.Watch(d.ctx, watched...).Err(); err != nil {
+		return nil, err
+	}
+
+	pip := d.tx.Pipeline()
+	refs := make([]func() map[string][]string, len(ids))
+
+	for i, refID := range ids {
+		refs[i] = queueBackrefReads(pip, refPrefix, refID, refTyp)
+	}
+
+	if _, err := pip.Exec(d.ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	referrers := make([]referrer, len(ids))
+
+	for i, refID := range ids {
+		referrers[i] = referrer{refID, refs[i]()}
+	}
+
+	return referrers, nil
+}
+
+// queueDelete adds the commands needed to delete prefix:id to pip. oldRefs
+// holds the IDs the object references through its backref fields, from
+// queueBackrefReads.
+func queueDelete(pip redis.Pipeliner, prefix, id string, typ reflect.Type, oldRefs map[string][]string) {
+	pip.Del(ctx, prefix+":"+id)
+
+	for _, k := range schemaOf(typ).subKeys {
+		pip.Del(ctx, prefix+":"+id+":"+k)
+	}
+
+	for _, br := range schemaOf(typ).backrefs {
+		queueBackrefMove(pip, br.refPrefix, prefix, id, oldRefs[br.key], nil)
+	}
+}
+
+// contains returns true if ids contains id.
+func contains(ids []string, id string) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+
+	return false
+}
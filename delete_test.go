@@ -0,0 +1,211 @@
+package grocery
+
+import (
+	"testing"
+)
+
+func TestDelete(t *testing.T) {
+	model := &LoadTestModel{
+		StringVal: "hello world",
+		MapVal:    NewMap(map[string]string{"a": "b"}),
+		SetVal:    NewSet([]string{"a"}),
+	}
+
+	id, err := Store(model)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if err := Delete(id, new(LoadTestModel)); err != nil {
+		t.Error(err)
+	}
+
+	if n, _ := C.Exists(ctx, "loadtestmodel:"+id, "loadtestmodel:"+id+":mapVal", "loadtestmodel:"+id+":setVal").Result(); n != 0 {
+		t.Errorf("delete FAILED, expected all keys to be deleted but %d still exist", n)
+	}
+
+	if err := Delete(id, new(LoadTestModel)); err == nil {
+		t.Errorf("delete FAILED, expected an error for a missing object")
+	}
+}
+
+type PolicySupplier struct {
+	Base
+	Name string `grocery:"name"`
+}
+
+type PolicyStore struct {
+	Base
+	Name string `grocery:"name"`
+}
+
+type PolicyBasket struct {
+	Base
+	Name string `grocery:"name"`
+}
+
+type PolicyFruit struct {
+	Base
+	Name     string          `grocery:"name"`
+	Supplier *PolicySupplier `grocery:"supplier,ondelete=cascade"`
+	Store    *PolicyStore    `grocery:"store,ondelete=restrict"`
+	Baskets  []*PolicyBasket `grocery:"baskets,ondelete=setnull"`
+}
+
+func TestDeleteCascade(t *testing.T) {
+	Register(new(PolicyFruit))
+
+	supplier := &PolicySupplier{Name: "supplier"}
+	supplierID, _ := Store(supplier)
+
+	fruitID, _ := Store(&PolicyFruit{Name: "mango", Supplier: supplier})
+
+	if err := Delete(supplierID, new(PolicySupplier)); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if n, _ := C.Exists(ctx, "policyfruit:"+fruitID).Result(); n != 0 {
+		t.Errorf("delete cascade FAILED, expected referencing object to be deleted")
+	}
+}
+
+func TestDeleteRestrict(t *testing.T) {
+	Register(new(PolicyFruit))
+
+	store := &PolicyStore{Name: "store"}
+	storeID, _ := Store(store)
+
+	fruitID, _ := Store(&PolicyFruit{Name: "mango", Store: store})
+
+	if err := Delete(storeID, new(PolicyStore)); err == nil {
+		t.Errorf("delete restrict FAILED, expected an error")
+	}
+
+	if n, _ := C.Exists(ctx, "policystore:"+storeID).Result(); n != 1 {
+		t.Errorf("delete restrict FAILED, expected referenced object to still exist")
+	}
+
+	// Deleting the fruit first lifts the restriction
+	Delete(fruitID, new(PolicyFruit))
+
+	if err := Delete(storeID, new(PolicyStore)); err != nil {
+		t.Errorf("delete restrict FAILED, got error %v", err)
+	}
+}
+
+func TestDeleteSetNull(t *testing.T) {
+	Register(new(PolicyFruit))
+
+	b1 := &PolicyBasket{Name: "one"}
+	b1ID, _ := Store(b1)
+
+	b2 := &PolicyBasket{Name: "two"}
+	Store(b2)
+
+	fruitID, _ := Store(&PolicyFruit{Name: "mango", Baskets: []*PolicyBasket{b1, b2}})
+
+	if err := Delete(b1ID, new(PolicyBasket)); err != nil {
+		t.Error(err)
+		return
+	}
+
+	fruit := new(PolicyFruit)
+
+	if err := Load(fruitID, fruit); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(fruit.Baskets) != 1 || fruit.Baskets[0].ID != b2.ID {
+		t.Errorf("delete set null FAILED, expected only %s to remain", b2.ID)
+	}
+}
+
+type RacingFruit struct {
+	Base
+	Name     string          `grocery:"name"`
+	Supplier *PolicySupplier `grocery:"supplier,ondelete=cascade"`
+}
+
+// Stores another fruit from racingSupplier while the supplier's deletion is
+// being planned, once its fruits have already been read.
+var racingSupplier *PolicySupplier
+var racingFruitID string
+
+func (f *RacingFruit) PreDelete() error {
+	if racingSupplier != nil && racingFruitID == "" {
+		racingFruitID, _ = Store(&RacingFruit{Name: "late", Supplier: racingSupplier})
+	}
+
+	return nil
+}
+
+func TestDeleteConcurrentReferrer(t *testing.T) {
+	Register(new(RacingFruit))
+
+	supplier := &PolicySupplier{Name: "supplier"}
+	supplierID, _ := Store(supplier)
+	Store(&RacingFruit{Name: "early", Supplier: supplier})
+
+	racingSupplier, racingFruitID = supplier, ""
+	defer func() { racingSupplier = nil }()
+
+	if err := Delete(supplierID, new(PolicySupplier)); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if racingFruitID == "" {
+		t.Errorf("delete concurrent FAILED, expected a fruit to be stored during the delete")
+	} else if n, _ := C.Exists(ctx, "racingfruit:"+racingFruitID).Result(); n != 0 {
+		t.Errorf("delete concurrent FAILED, expected the fruit stored during the delete to be cascaded")
+	}
+}
+
+type UnknownRefTarget struct {
+	Base
+	Name string `grocery:"name"`
+}
+
+type UnknownRefFruit struct {
+	Base
+	Target *UnknownRefTarget `grocery:"target,backref"`
+}
+
+func TestDeleteUnknownReferrer(t *testing.T) {
+	target := &UnknownRefTarget{Name: "target"}
+	targetID, _ := Store(target)
+	Store(&UnknownRefFruit{Target: target})
+
+	// Forget the referencing type, as a new process would
+	models.Delete("unknownreffruit")
+
+	if err := Delete(targetID, new(UnknownRefTarget)); err == nil {
+		t.Errorf("delete unknown referrer FAILED, expected an error")
+	}
+
+	if err := Register(new(UnknownRefFruit)); err != nil {
+		t.Error(err)
+	}
+
+	if err := Delete(targetID, new(UnknownRefTarget)); err != nil {
+		t.Errorf("delete unknown referrer FAILED, got error %v", err)
+	}
+}
+
+type UnknownPolicyFruit struct {
+	Base
+	Supplier *PolicySupplier `grocery:"supplier,ondelete=explode"`
+}
+
+func TestUnknownDeletePolicy(t *testing.T) {
+	if err := Register(new(UnknownPolicyFruit)); err == nil {
+		t.Errorf("unknown policy FAILED, expected Register to fail")
+	}
+
+	if _, err := Store(&UnknownPolicyFruit{}); err == nil {
+		t.Errorf("unknown policy FAILED, expected Store to fail")
+	}
+}
@@ -0,0 +1,252 @@
+package grocery
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Ways of dispatching messages to listeners, which may be set as
+// DispatchOptions.Mode.
+const (
+	// Messages published to the same channel are handled one at a time, in
+	// the order they're received. Messages published to different channels
+	// are handled concurrently.
+	DispatchOrdered = "ordered"
+
+	// Messages are handled concurrently by a pool of workers, in any order.
+	DispatchPool = "pool"
+)
+
+const (
+	// Number of workers handling messages, unless DispatchOptions.Workers is
+	// set.
+	defaultWorkers = 8
+
+	// Number of messages that may wait for each queue of workers, unless
+	// DispatchOptions.QueueSize is set.
+	defaultQueueSize = 1000
+)
+
+var (
+	// ErrHandlerTimeout is reported to DispatchOptions.OnError when a handler
+	// runs for longer than DispatchOptions.Timeout.
+	ErrHandlerTimeout = errors.New("handler timed out")
+
+	// ErrMessageDropped is reported to DispatchOptions.OnError when a message
+	// is received while its queue is full.
+	ErrMessageDropped = errors.New("message dropped, queue is full")
+)
+
+// DispatchOptions provides options that may be passed to InitWithOptions to
+// change how messages are handed to the functions added with Subscribe and
+// Watch.
+type DispatchOptions struct {
+	// DispatchOrdered or DispatchPool. Defaults to DispatchOrdered.
+	Mode string
+
+	// Workers is the number of messages that may be handled at once.
+	// Defaults to 8.
+	Workers int
+
+	// QueueSize is the number of received messages that may wait to be
+	// handled by each worker, or by all of them with DispatchPool. Messages
+	// received while the queue is full are dropped. Defaults to 1000.
+	QueueSize int
+
+	// Timeout is the longest each handler may run before the worker moves
+	// on to the next one. Handlers that time out keep running, but may then
+	// run alongside later messages on the same channel, and Close still
+	// waits for them to return. Zero means there is no limit.
+	Timeout time.Duration
+
+	// OnError is called with the channel and the error whenever a message
+	// is dropped, or a handler panics or times out. The listener keeps
+	// running either way.
+	OnError func(channel string, err error)
+}
+
+// Counters reported by PubSubStatus, updated atomically.
+var (
+	dropped  int64
+	panics   int64
+	timeouts int64
+)
+
+// delivery is a received message that's waiting to be handled.
+type delivery struct {
+	channel   string
+	payload   []byte
+	listeners []*listener
+}
+
+// dispatcher hands received messages to workers, which call their listeners.
+type dispatcher struct {
+	opts DispatchOptions
+
+	// Queues of messages, each with its own workers when ordered.
+	queues []chan delivery
+
+	// Handlers called with a timeout, which may still be running after
+	// their worker has moved on.
+	running sync.WaitGroup
+
+	// Closed once every worker and handler has returned, after stop.
+	done chan struct{}
+}
+
+// newDispatcher starts the workers for a dispatcher with the given options,
+// which may be nil.
+func newDispatcher(opts *DispatchOptions) *dispatcher {
+	d := &dispatcher{done: make(chan struct{})}
+
+	if opts != nil {
+		d.opts = *opts
+	}
+
+	if d.opts.Mode == "" {
+		d.opts.Mode = DispatchOrdered
+	}
+
+	if d.opts.Workers <= 0 {
+		d.opts.Workers = defaultWorkers
+	}
+
+	if d.opts.QueueSize <= 0 {
+		d.opts.QueueSize = defaultQueueSize
+	}
+
+	queues := 1
+
+	if d.opts.Mode == DispatchOrdered {
+		queues = d.opts.Workers
+	}
+
+	for i := 0; i < queues; i++ {
+		d.queues = append(d.queues, make(chan delivery, d.opts.QueueSize))
+	}
+
+	var workers sync.WaitGroup
+	workers.Add(d.opts.Workers)
+
+	for i := 0; i < d.opts.Workers; i++ {
+		go func(queue chan delivery) {
+			defer workers.Done()
+			d.work(queue)
+		}(d.queues[i%queues])
+	}
+
+	go func() {
+		// Handlers are only started by workers, so none can time out once
+		// they've returned
+		workers.Wait()
+		d.running.Wait()
+		close(d.done)
+	}()
+
+	return d
+}
+
+// dispatch queues a message for listeners, or drops it if its queue is full.
+func (d *dispatcher) dispatch(channel string, payload []byte, listeners []*listener) {
+	queue := d.queues[0]
+
+	if len(d.queues) > 1 {
+		// Keep each channel on the same worker, so that its messages stay
+		// in order
+		h := fnv.New32a()
+		h.Write([]byte(channel))
+		queue = d.queues[h.Sum32()%uint32(len(d.queues))]
+	}
+
+	select {
+	case queue <- delivery{channel, payload, listeners}:
+	default:
+		atomic.AddInt64(&dropped, 1)
+		d.report(channel, ErrMessageDropped)
+	}
+}
+
+// depth returns the number of messages waiting to be handled.
+func (d *dispatcher) depth() int {
+	depth := 0
+
+	for _, queue := range d.queues {
+		depth += len(queue)
+	}
+
+	return depth
+}
+
+// stop stops the workers once they've handled the messages already queued,
+// after which done is closed.
+func (d *dispatcher) stop() {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+}
+
+func (d *dispatcher) work(queue chan delivery) {
+	for del := range queue {
+		for _, l := range del.listeners {
+			d.call(l, del)
+		}
+	}
+}
+
+// call calls the handler of l with the message in del, waiting for it for up
+// to the dispatcher's timeout.
+func (d *dispatcher) call(l *listener, del delivery) {
+	if d.opts.Timeout <= 0 {
+		if err := callHandler(l, del); err != nil {
+			d.report(del.channel, err)
+		}
+
+		return
+	}
+
+	done := make(chan error, 1)
+	d.running.Add(1)
+
+	go func() {
+		defer d.running.Done()
+		done <- callHandler(l, del)
+	}()
+
+	timer := time.NewTimer(d.opts.Timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			d.report(del.channel, err)
+		}
+	case <-timer.C:
+		atomic.AddInt64(&timeouts, 1)
+		d.report(del.channel, ErrHandlerTimeout)
+	}
+}
+
+// report passes err to the dispatcher's OnError function, if it has one.
+func (d *dispatcher) report(channel string, err error) {
+	if d.opts.OnError != nil {
+		d.opts.OnError(channel, err)
+	}
+}
+
+// callHandler calls the handler of l with the message in del, and returns an
+// error if it panics.
+func callHandler(l *listener, del delivery) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddInt64(&panics, 1)
+			err = fmt.Errorf("handler for %s panicked: %v", del.channel, r)
+		}
+	}()
+
+	l.handler(del.channel, del.payload)
+	return nil
+}
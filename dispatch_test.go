@@ -0,0 +1,148 @@
+package grocery
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatchOrdered(t *testing.T) {
+	d := newDispatcher(&DispatchOptions{Workers: 4})
+	defer d.stop()
+
+	var mux sync.Mutex
+	received := make(map[string][]string)
+	var wg sync.WaitGroup
+
+	l := &listener{func(channel string, payload []byte) {
+		mux.Lock()
+		received[channel] = append(received[channel], string(payload))
+		mux.Unlock()
+		wg.Done()
+	}}
+
+	for i := 0; i < 100; i++ {
+		for _, channel := range []string{"a", "b", "c"} {
+			wg.Add(1)
+			d.dispatch(channel, []byte{byte(i)}, []*listener{l})
+		}
+	}
+
+	wg.Wait()
+
+	for channel, payloads := range received {
+		for i, payload := range payloads {
+			if payload != string([]byte{byte(i)}) {
+				t.Fatalf("TestDispatchOrdered FAILED, expected messages on %s in order", channel)
+			}
+		}
+	}
+}
+
+func TestDispatchErrors(t *testing.T) {
+	reported := make(chan error, 10)
+
+	d := newDispatcher(&DispatchOptions{
+		Mode:      DispatchPool,
+		Workers:   1,
+		QueueSize: 1,
+		Timeout:   time.Millisecond * 50,
+		OnError: func(channel string, err error) {
+			reported <- err
+		},
+	})
+
+	defer d.stop()
+
+	before := PubSubStatus()
+	release := make(chan struct{})
+	handled := make(chan string, 10)
+
+	l := &listener{func(channel string, payload []byte) {
+		switch string(payload) {
+		case "panic":
+			panic("oops")
+		case "slow":
+			<-release
+		}
+
+		handled <- string(payload)
+	}}
+
+	d.dispatch("dispatchtest", []byte("panic"), []*listener{l})
+
+	if err := <-reported; !strings.Contains(err.Error(), "panicked: oops") {
+		t.Errorf("TestDispatchErrors FAILED, expected panic to be reported, got %v", err)
+	}
+
+	d.dispatch("dispatchtest", []byte("ok"), []*listener{l})
+
+	if payload := <-handled; payload != "ok" {
+		t.Errorf("TestDispatchErrors FAILED, expected handlers to keep running after a panic, got %s", payload)
+	}
+
+	// The worker is held up by the slow handler until it times out, so the
+	// queue fills up
+	d.dispatch("dispatchtest", []byte("slow"), []*listener{l})
+	time.Sleep(time.Millisecond * 10)
+	d.dispatch("dispatchtest", []byte("queued"), []*listener{l})
+	d.dispatch("dispatchtest", []byte("dropped"), []*listener{l})
+
+	if err := <-reported; !errors.Is(err, ErrMessageDropped) {
+		t.Errorf("TestDispatchErrors FAILED, expected message to be dropped, got %v", err)
+	} else if err := <-reported; !errors.Is(err, ErrHandlerTimeout) {
+		t.Errorf("TestDispatchErrors FAILED, expected handler to time out, got %v", err)
+	}
+
+	if payload := <-handled; payload != "queued" {
+		t.Errorf("TestDispatchErrors FAILED, expected queued message after the timeout, got %s", payload)
+	}
+
+	close(release)
+	<-handled
+
+	status := PubSubStatus()
+
+	if status.Panics != before.Panics+1 || status.Dropped != before.Dropped+1 || status.Timeouts != before.Timeouts+1 {
+		t.Errorf("TestDispatchErrors FAILED, expected counters to increase by one, got %+v", status)
+	}
+}
+
+func TestDispatchStopWaitsForTimedOut(t *testing.T) {
+	reported := make(chan error, 1)
+
+	d := newDispatcher(&DispatchOptions{
+		Workers: 1,
+		Timeout: time.Millisecond * 10,
+		OnError: func(channel string, err error) {
+			reported <- err
+		},
+	})
+
+	release := make(chan struct{})
+	l := &listener{func(channel string, payload []byte) { <-release }}
+
+	d.dispatch("dispatchtest", []byte("slow"), []*listener{l})
+
+	if err := <-reported; !errors.Is(err, ErrHandlerTimeout) {
+		t.Fatalf("TestDispatchStopWaitsForTimedOut FAILED, expected handler to time out, got %v", err)
+	}
+
+	d.stop()
+
+	select {
+	case <-d.done:
+		t.Errorf("TestDispatchStopWaitsForTimedOut FAILED, expected dispatcher to wait for the timed out handler")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	close(release)
+
+	select {
+	case <-d.done:
+	case <-time.After(time.Second):
+		t.Errorf("TestDispatchStopWaitsForTimedOut FAILED, expected dispatcher to stop once the handler returned")
+	}
+}
@@ -0,0 +1,102 @@
+package grocery
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+//go:generate go run ./cmd/grocery-gen -type GenTestModel -output gentestmodel_grocery_test.go
+
+type GenTestModel struct {
+	Base
+
+	Name     string       `grocery:"name"`
+	Alias    CustomString `grocery:"alias"`
+	Count    int          `grocery:"count"`
+	Small    int8         `grocery:"small"`
+	Unsigned uint16       `grocery:"unsigned"`
+	Large    uint64       `grocery:"large"`
+	Price    float64      `grocery:"price"`
+	Ratio    float32      `grocery:"ratio"`
+	Active   bool         `grocery:"active"`
+	Seen     time.Time    `grocery:"seen"`
+	Skipped  string       `grocery:"-"`
+}
+
+// ReflectTestModel has the same fields as GenTestModel, without generated
+// methods.
+type ReflectTestModel struct {
+	Base
+
+	Name     string       `grocery:"name"`
+	Alias    CustomString `grocery:"alias"`
+	Count    int          `grocery:"count"`
+	Small    int8         `grocery:"small"`
+	Unsigned uint16       `grocery:"unsigned"`
+	Large    uint64       `grocery:"large"`
+	Price    float64      `grocery:"price"`
+	Ratio    float32      `grocery:"ratio"`
+	Active   bool         `grocery:"active"`
+	Seen     time.Time    `grocery:"seen"`
+	Skipped  string       `grocery:"-"`
+}
+
+func TestGeneratedLayout(t *testing.T) {
+	if !schemaOf(reflect.TypeOf(GenTestModel{})).marshaler {
+		t.Fatalf("TestGeneratedLayout FAILED, expected GenTestModel to use MarshalGrocery")
+	}
+
+	seen := time.Unix(963210120, 0)
+	gen := &GenTestModel{Name: "a", Alias: "b", Count: -4, Small: 3, Unsigned: 65432, Large: math.MaxUint64 - 5, Price: -2190.3895, Ratio: 0.5, Active: true, Seen: seen, Skipped: "x"}
+	ref := &ReflectTestModel{Name: "a", Alias: "b", Count: -4, Small: 3, Unsigned: 65432, Large: math.MaxUint64 - 5, Price: -2190.3895, Ratio: 0.5, Active: true, Seen: seen, Skipped: "x"}
+
+	genID, err := Store(gen)
+
+	if err != nil {
+		t.Fatalf("TestGeneratedLayout FAILED, got error %v", err)
+	}
+
+	refID, err := Store(ref)
+
+	if err != nil {
+		t.Fatalf("TestGeneratedLayout FAILED, got error %v", err)
+	}
+
+	genData := C.HGetAll(ctx, "gentestmodel:"+genID).Val()
+	refData := C.HGetAll(ctx, "reflecttestmodel:"+refID).Val()
+
+	if !reflect.DeepEqual(genData, refData) {
+		t.Errorf("TestGeneratedLayout FAILED, expected %v but got %v", refData, genData)
+	}
+
+	loaded := new(GenTestModel)
+
+	if err := Load(genID, loaded); err != nil {
+		t.Fatalf("TestGeneratedLayout FAILED, got error %v", err)
+	}
+
+	gen.Skipped = ""
+	gen.ID = genID
+
+	if loaded.CreatedAt.IsZero() || loaded.UpdatedAt.IsZero() {
+		t.Errorf("TestGeneratedLayout FAILED, expected Base to be loaded")
+	}
+
+	loaded.Base = gen.Base
+
+	if !reflect.DeepEqual(loaded, gen) {
+		t.Errorf("TestGeneratedLayout FAILED, expected %+v but got %+v", gen, loaded)
+	}
+
+	if err := Update(genID, &GenTestModel{Count: 5}); err != nil {
+		t.Fatalf("TestGeneratedLayout FAILED, got error %v", err)
+	}
+
+	Load(genID, loaded)
+
+	if loaded.Count != 5 || loaded.Name != "a" {
+		t.Errorf("TestGeneratedLayout FAILED, expected only count to be updated but got %+v", loaded)
+	}
+}
@@ -0,0 +1,175 @@
+// Code generated by grocery-gen. DO NOT EDIT.
+
+package grocery
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MarshalGrocery writes the fields of m to the hash at key.
+func (m *GenTestModel) MarshalGrocery(ctx context.Context, pip redis.Pipeliner, key string, setZeroValues bool) error {
+	if setZeroValues || m.Name != "" {
+		pip.HSet(ctx, key, "name", m.Name)
+	}
+
+	if setZeroValues || m.Alias != "" {
+		pip.HSet(ctx, key, "alias", string(m.Alias))
+	}
+
+	if setZeroValues || m.Count != 0 {
+		pip.HSet(ctx, key, "count", m.Count)
+	}
+
+	if setZeroValues || m.Small != 0 {
+		pip.HSet(ctx, key, "small", int(m.Small))
+	}
+
+	if setZeroValues || m.Unsigned != 0 {
+		pip.HSet(ctx, key, "unsigned", strconv.FormatUint(uint64(m.Unsigned), 10))
+	}
+
+	if setZeroValues || m.Large != 0 {
+		pip.HSet(ctx, key, "large", strconv.FormatUint(m.Large, 10))
+	}
+
+	if setZeroValues || m.Price != 0 {
+		pip.HSet(ctx, key, "price", m.Price)
+	}
+
+	if setZeroValues || m.Ratio != 0 {
+		pip.HSet(ctx, key, "ratio", float64(m.Ratio))
+	}
+
+	if setZeroValues || m.Active {
+		pip.HSet(ctx, key, "active", m.Active)
+	}
+
+	if setZeroValues || m.Seen != (time.Time{}) {
+		pip.HSet(ctx, key, "seen", m.Seen.Unix())
+	}
+
+	return nil
+}
+
+// UnmarshalGrocery reads the fields of m from the result of HGetAll.
+func (m *GenTestModel) UnmarshalGrocery(data map[string]string) error {
+	if v, ok := data["name"]; ok {
+		m.Name = v
+	}
+
+	if v, ok := data["alias"]; ok {
+		m.Alias = CustomString(v)
+	}
+
+	if v, ok := data["count"]; ok {
+		if v == "" {
+			v = "0"
+		}
+
+		n, err := strconv.ParseInt(v, 10, 0)
+
+		if err != nil {
+			return err
+		}
+
+		m.Count = int(n)
+	}
+
+	if v, ok := data["small"]; ok {
+		if v == "" {
+			v = "0"
+		}
+
+		n, err := strconv.ParseInt(v, 10, 8)
+
+		if err != nil {
+			return err
+		}
+
+		m.Small = int8(n)
+	}
+
+	if v, ok := data["unsigned"]; ok {
+		if v == "" {
+			v = "0"
+		}
+
+		n, err := strconv.ParseUint(v, 10, 16)
+
+		if err != nil {
+			return err
+		}
+
+		m.Unsigned = uint16(n)
+	}
+
+	if v, ok := data["large"]; ok {
+		if v == "" {
+			v = "0"
+		}
+
+		n, err := strconv.ParseUint(v, 10, 64)
+
+		if err != nil {
+			return err
+		}
+
+		m.Large = n
+	}
+
+	if v, ok := data["price"]; ok {
+		if v == "" {
+			v = "0.0"
+		}
+
+		n, err := strconv.ParseFloat(v, 64)
+
+		if err != nil {
+			return err
+		}
+
+		m.Price = n
+	}
+
+	if v, ok := data["ratio"]; ok {
+		if v == "" {
+			v = "0.0"
+		}
+
+		n, err := strconv.ParseFloat(v, 32)
+
+		if err != nil {
+			return err
+		}
+
+		m.Ratio = float32(n)
+	}
+
+	if v, ok := data["active"]; ok {
+		if v == "" {
+			v = "false"
+		}
+
+		b, err := strconv.ParseBool(v)
+
+		if err != nil {
+			return err
+		}
+
+		m.Active = b
+	}
+
+	if v, ok := data["seen"]; ok {
+		n, _ := strconv.ParseInt(v, 10, 64)
+
+		if m.Seen.Unix() != n {
+			m.Seen = time.Unix(n, 0)
+		}
+	}
+
+	return nil
+}
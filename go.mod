@@ -1,13 +1,18 @@
 module github.com/nytimes/grocery
 
-go 1.18
+go 1.19
 
 require (
 	github.com/google/uuid v1.4.0
 	github.com/redis/go-redis/v9 v9.3.0
+	go.opentelemetry.io/otel v1.16.0
+	go.opentelemetry.io/otel/metric v1.16.0
+	go.opentelemetry.io/otel/trace v1.16.0
 )
 
 require (
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/go-logr/logr v1.2.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
 )
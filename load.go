@@ -1,6 +1,7 @@
 package grocery
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"strings"
@@ -8,6 +9,30 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrNotFound is used by LoadEach to report objects that don't exist in
+// Redis.
+var ErrNotFound = errors.New("object not found")
+
+// LoadOptions provides options that may be passed to LoadWithOptions if the
+// default behavior of Load needs to be changed.
+type LoadOptions struct {
+	// MaxDepth limits how many levels of references are loaded from Redis.
+	// For example, a MaxDepth of 1 loads the object's references, but not
+	// their references. References beyond this depth are left as stubs, with
+	// only their ID set. Zero means there is no limit.
+	MaxDepth int
+
+	// Include lists the references that should be loaded, by their grocery
+	// keys joined with dots (e.g. "supplier" or "supplier.owner"). Including
+	// a nested reference also includes each of its parents. References that
+	// aren't included are left as stubs, with only their ID set. If Include
+	// is nil, all references are loaded.
+	Include []string
+
+	// Context of the operation this load is part of, if any.
+	ctx context.Context
+}
+
 // Load automates the process of loading data from Redis, binding it to a
 // struct, and then setting base attributes (such as ID). See Store for more
 // information on creating structs for grocery. Load can be used like so:
@@ -20,7 +45,16 @@ import (
 //	itemID := "asdf"
 //	item := new(Item)
 //	db.Load(itemID, item)
+//
+// All of the object's references are loaded along with it. A reference that
+// has already been loaded, such as one that points back to the object itself,
+// is set to the existing instance instead of being loaded again.
 func Load(id string, ptr interface{}) error {
+	return LoadWithOptions(id, ptr, &LoadOptions{})
+}
+
+// LoadWithOptions loads an object from Redis, like Load, but with options.
+func LoadWithOptions(id string, ptr interface{}, opts *LoadOptions) error {
 	if reflect.TypeOf(ptr).Kind() != reflect.Ptr || reflect.TypeOf(ptr).Elem().Kind() != reflect.Struct {
 		return errors.New("ptr must be a struct pointer")
 	}
@@ -28,31 +62,30 @@ func Load(id string, ptr interface{}) error {
 	// Get prefix for the struct (e.g. 'item:' from Item)
 	prefix := strings.ToLower(reflect.TypeOf(ptr).Elem().Name())
 
-	// Load object data
-	res, err := C.HGetAll(ctx, prefix+":"+id).Result()
-
-	if err != nil {
-		return err
-	} else if err := bind(prefix, id, res, ptr); err != nil {
+	if err := register(reflect.TypeOf(ptr).Elem()); err != nil {
 		return err
 	}
 
-	// Set the ID before returning
-	val := reflect.ValueOf(ptr)
-	fi := reflect.Indirect(val).FieldByName("ID")
-
-	if fi.String() != id {
-		fi.SetString(id)
+	if opts == nil {
+		opts = &LoadOptions{}
 	}
 
-	// Call post-load hook
-	postLoad := reflect.ValueOf(ptr).MethodByName("PostLoad")
+	op := startOperation(opts.ctx, "grocery.Load", prefix, id)
 
-	if postLoad.IsValid() {
-		postLoad.Call([]reflect.Value{})
+	// Load object data
+	res, err := C.HGetAll(op.ctx, prefix+":"+id).Result()
+
+	if err != nil {
+		return op.end(err)
 	}
 
-	return nil
+	l := newLoader(opts)
+	l.ctx = op.ctx
+	err = l.bind(prefix, id, res, ptr)
+	op.setFields(len(res))
+	op.setReferences(l.references)
+
+	return op.end(err)
 }
 
 // LoadAll automates the process of running multiple Load calls through a
@@ -60,6 +93,12 @@ func Load(id string, ptr interface{}) error {
 // generally use LoadAll instead of calling Load multiple times. Read more
 // about pipelining at https://redis.io/topics/pipelining.
 func LoadAll[T any](ids []string, values *[]T) error {
+	return LoadAllWithOptions(ids, values, &LoadOptions{})
+}
+
+// LoadAllWithOptions loads multiple objects from Redis, like LoadAll, but with
+// options.
+func LoadAllWithOptions[T any](ids []string, values *[]T, opts *LoadOptions) error {
 	if len(ids) != len(*values) {
 		return errors.New("len(ids) must equal len(*values)")
 	} else if len(ids) == 0 {
@@ -69,6 +108,16 @@ func LoadAll[T any](ids []string, values *[]T) error {
 	// Get prefix for the struct (e.g. 'item:' from Item)
 	prefix := strings.ToLower(reflect.ValueOf(values).Elem().Index(0).Type().Name())
 
+	if err := register(reflect.ValueOf(values).Elem().Index(0).Type()); err != nil {
+		return err
+	}
+
+	if opts == nil {
+		opts = &LoadOptions{}
+	}
+
+	op := startOperation(opts.ctx, "grocery.LoadAll", prefix, "")
+
 	// Pipeline all HGetAll commands
 	pip := C.Pipeline()
 	cmds := make([]*redis.MapStringStringCmd, len(ids))
@@ -77,31 +126,142 @@ func LoadAll[T any](ids []string, values *[]T) error {
 		cmds[i] = pip.HGetAll(ctx, prefix+":"+id)
 	}
 
-	pip.Exec(ctx)
+	if _, err := pip.Exec(op.ctx); err != nil {
+		return op.end(err)
+	}
+
+	// Bind every object with one loader, so that their references are
+	// fetched together, and references they share are only loaded once
+	l := newLoader(opts)
+	l.ctx = op.ctx
+	jobs := make([]*loadJob, len(ids))
+	fields := 0
 
 	for i, cmd := range cmds {
 		res, _ := cmd.Result()
-		itemPtr := &((*values)[i])
+		job, err := l.root(prefix, ids[i], res, &((*values)[i]))
+
+		if err != nil {
+			return op.end(err)
+		}
+
+		jobs[i] = job
+		fields += len(res)
+	}
+
+	err := l.load(jobs)
+	op.setFields(fields)
+	op.setReferences(l.references)
+
+	return op.end(err)
+}
 
-		if err := bind(prefix, ids[i], res, itemPtr); err != nil {
-			return err
+// LoadResult is the result of loading a single object with LoadEach.
+type LoadResult[T any] struct {
+	// The ID of the object.
+	ID string
+
+	// The loaded object, or nil if it couldn't be loaded.
+	Value *T
+
+	// ErrNotFound if the object doesn't exist, or any other error that
+	// occurred while binding its data, or the data of the objects it
+	// references. A referenced object that's shared by several objects is
+	// only bound once, so its errors are only reported for the first of
+	// them.
+	Err error
+}
+
+// LoadEach loads multiple objects through a pipeline, like LoadAll, but
+// allocates each object itself and reports a result for every ID, in the same
+// order as ids. Objects that can't be loaded, such as ones that don't exist,
+// don't affect the rest of the results. An error is only returned if Redis
+// couldn't be queried.
+//
+//	results, err := db.LoadEach[Item](itemIDs, nil)
+//
+//	for _, res := range results {
+//	    if errors.Is(res.Err, db.ErrNotFound) {
+//	        continue
+//	    }
+//	    ...
+//	}
+func LoadEach[T any](ids []string, opts *LoadOptions) ([]LoadResult[T], error) {
+	results := make([]LoadResult[T], len(ids))
+
+	if len(ids) == 0 {
+		return results, nil
+	}
+
+	// Get prefix for the struct (e.g. 'item:' from Item)
+	prefix := strings.ToLower(reflect.TypeOf((*T)(nil)).Elem().Name())
+
+	if err := register(reflect.TypeOf((*T)(nil)).Elem()); err != nil {
+		return nil, err
+	}
+
+	if opts == nil {
+		opts = &LoadOptions{}
+	}
+
+	op := startOperation(opts.ctx, "grocery.LoadEach", prefix, "")
+
+	// Pipeline all HGetAll commands
+	pip := C.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+
+	for i, id := range ids {
+		cmds[i] = pip.HGetAll(ctx, prefix+":"+id)
+	}
+
+	if _, err := pip.Exec(op.ctx); err != nil {
+		return nil, op.end(err)
+	}
+
+	l := newLoader(opts)
+	l.ctx = op.ctx
+	l.rootErrors = true
+	jobs := make([]*loadJob, len(ids))
+	found := make([]*loadJob, 0, len(ids))
+	fields := 0
+
+	for i, cmd := range cmds {
+		results[i].ID = ids[i]
+		res := cmd.Val()
+		fields += len(res)
+
+		if len(res) == 0 {
+			results[i].Err = ErrNotFound
+			continue
 		}
 
-		// Set ID
-		val := reflect.ValueOf(itemPtr).Elem()
-		fi := reflect.Indirect(val).FieldByName("ID")
+		results[i].Value = new(T)
+		job, err := l.root(prefix, ids[i], res, results[i].Value)
 
-		if fi.String() != ids[i] {
-			fi.SetString(ids[i])
+		if err != nil {
+			results[i].Value = nil
+			results[i].Err = err
+			continue
 		}
 
-		// Call post-load hook
-		postLoad := reflect.ValueOf(itemPtr).MethodByName("PostLoad")
+		jobs[i] = job
+		found = append(found, job)
+	}
+
+	err := l.load(found)
+	op.setFields(fields)
+	op.setReferences(l.references)
+
+	if err := op.end(err); err != nil {
+		return nil, err
+	}
 
-		if postLoad.IsValid() {
-			postLoad.Call([]reflect.Value{})
+	for i := range results {
+		if jobs[i] != nil && jobs[i].err != nil {
+			results[i].Value = nil
+			results[i].Err = jobs[i].err
 		}
 	}
 
-	return nil
+	return results, nil
 }
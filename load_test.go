@@ -147,3 +147,88 @@ func TestLoadAll(t *testing.T) {
 		t.Errorf("id FAILED, expected %s but got %s", id, model.ID)
 	}
 }
+
+func TestLoadEach(t *testing.T) {
+	model := &LoadTestModel{
+		StringVal: "hello world",
+		MapVal:    NewMap(map[string]string{"a": "b"}),
+	}
+
+	id, err := Store(model)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	results, err := LoadEach[LoadTestModel]([]string{"asdf", id}, nil)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(results) != 2 {
+		t.Errorf("load each FAILED, expected 2 results but got %d", len(results))
+		return
+	}
+
+	if results[0].Err != ErrNotFound || results[0].Value != nil {
+		t.Errorf("load each missing FAILED, expected ErrNotFound but got %v", results[0].Err)
+	}
+
+	if results[1].Err != nil {
+		t.Errorf("load each FAILED, got error %v", results[1].Err)
+	} else if results[1].Value.ID != id || results[1].Value.StringVal != model.StringVal {
+		t.Errorf("load each FAILED, expected %s but got %s", model.StringVal, results[1].Value.StringVal)
+	}
+
+	empty, err := LoadEach[LoadTestModel]([]string{}, nil)
+
+	if err != nil || len(empty) != 0 {
+		t.Errorf("load each empty FAILED, expected no results but got %d, %v", len(empty), err)
+	}
+}
+
+type LoadEachRefTest struct {
+	Base
+	Ref         *LoadTestModel `grocery:"ref"`
+	Unsupported []A            `grocery:"unsupported"`
+}
+
+func TestLoadEachReferenceErrors(t *testing.T) {
+	broken := &LoadTestModel{StringVal: "broken"}
+	brokenID, _ := Store(broken)
+
+	// Break the referenced object's data
+	C.HSet(ctx, "loadtestmodel:"+brokenID, "intVal", "asdf")
+
+	good := &LoadTestModel{StringVal: "good"}
+	Store(good)
+
+	first, _ := Store(&LoadEachRefTest{Ref: broken})
+	second, _ := Store(&LoadEachRefTest{Ref: good})
+
+	third, _ := Store(&LoadEachRefTest{Ref: good})
+
+	// Give the third object a list that fails once it's fetched
+	C.RPush(ctx, "loadeachreftest:"+third+":unsupported", "asdf")
+
+	results, err := LoadEach[LoadEachRefTest]([]string{first, second, third}, nil)
+
+	if err != nil {
+		t.Errorf("load each reference FAILED, expected errors per result but got %v", err)
+		return
+	}
+
+	if results[0].Err == nil || results[0].Value != nil {
+		t.Errorf("load each reference FAILED, expected an error for %s", first)
+	}
+
+	if results[1].Err != nil || results[1].Value.Ref.StringVal != good.StringVal {
+		t.Errorf("load each reference FAILED, expected %s to load but got %v", second, results[1].Err)
+	}
+
+	if results[2].Err == nil || results[2].Value != nil {
+		t.Errorf("load each list FAILED, expected an error for %s", third)
+	}
+}
@@ -0,0 +1,51 @@
+package grocery
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Marshaler may be implemented by models to write their own fields to Redis
+// without reflection. It's usually generated by grocery-gen:
+//
+//	//go:generate go run github.com/nytimes/grocery/cmd/grocery-gen -type Item
+//
+// MarshalGrocery adds the commands that write the model's fields to the hash
+// at key to pip. Zero values are skipped unless setZeroValues is true, and
+// embedded structs such as Base are left to grocery. Hooks, validation and
+// the createdAt and updatedAt timestamps are still handled by Store and
+// Update as usual.
+type Marshaler interface {
+	MarshalGrocery(ctx context.Context, pip redis.Pipeliner, key string, setZeroValues bool) error
+}
+
+// Unmarshaler may be implemented by models to read their own fields from the
+// result of HGetAll without reflection. It's usually generated by
+// grocery-gen, along with MarshalGrocery. Embedded structs such as Base are
+// still bound by grocery before UnmarshalGrocery is called.
+type Unmarshaler interface {
+	UnmarshalGrocery(data map[string]string) error
+}
+
+var marshalerType = reflect.TypeOf((*Marshaler)(nil)).Elem()
+var unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+// implementsOwn returns true if pointers to the struct type typ implement
+// iface, and the methods aren't promoted from one of its embedded fields.
+// Promoted methods would only handle the embedded struct's fields, so types
+// that embed an implementation are always handled with reflection.
+func implementsOwn(typ reflect.Type, iface reflect.Type) bool {
+	if !reflect.PtrTo(typ).Implements(iface) {
+		return false
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		if field := typ.Field(i); field.Anonymous && (field.Type.Implements(iface) || reflect.PtrTo(field.Type).Implements(iface)) {
+			return false
+		}
+	}
+
+	return true
+}
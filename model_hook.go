@@ -11,3 +11,68 @@ type ModelHook interface {
 	// immediately after the store completes.
 	PostStore(pip redis.Pipeliner)
 }
+
+// PreStoreHook may be implemented by models that need to be called before
+// they're stored, including by StoreAll and when cascading.
+type PreStoreHook interface {
+	// PreStore is called before any commands to store the object are passed
+	// to a pipeline. Returning an error aborts the store.
+	PreStore() error
+}
+
+// PreUpdateHook may be implemented by models that need to be called before
+// they're updated, including by UpdateAll.
+type PreUpdateHook interface {
+	// PreUpdate is called before any commands to update the object are passed
+	// to a pipeline. Returning an error aborts the update.
+	PreUpdate() error
+}
+
+// PostUpdateHook may be implemented by models that need to be called after
+// they're updated.
+type PostUpdateHook interface {
+	// PostUpdate is called after all necessary commands to update the object
+	// have been passed to a pipeline, like PostStore.
+	PostUpdate(pip redis.Pipeliner)
+}
+
+// PreDeleteHook may be implemented by models that need to be called before
+// they're deleted, including when a delete policy cascades to them. The hook
+// is called on a struct with only its ID set, since Delete doesn't load the
+// objects it deletes.
+type PreDeleteHook interface {
+	// PreDelete is called before any commands to delete the object are passed
+	// to a pipeline. Returning an error aborts the whole delete.
+	PreDelete() error
+}
+
+// PostDeleteHook may be implemented by models that need to be called after
+// they're deleted. Like PreDeleteHook, it's called on a struct with only its
+// ID set.
+type PostDeleteHook interface {
+	// PostDelete is called after all necessary commands to delete the object
+	// have been passed to a pipeline, like PostStore.
+	PostDelete(pip redis.Pipeliner)
+}
+
+// PostLoadHook may be implemented by models that need to be called after
+// they're loaded.
+type PostLoadHook interface {
+	// PostLoad is called once the object and all of its references have been
+	// loaded, whether it was loaded directly by Load, LoadAll or LoadEach, or
+	// as another object's reference. References are loaded first, so their
+	// hooks are called before the hooks of the objects referencing them.
+	PostLoad()
+}
+
+// callPreWriteHook calls the PreStore or PreUpdate hook of ptr, depending on
+// whether it's being stored or updated.
+func callPreWriteHook(ptr interface{}, isStore bool) error {
+	if hook, ok := ptr.(PreStoreHook); ok && isStore {
+		return hook.PreStore()
+	} else if hook, ok := ptr.(PreUpdateHook); ok && !isStore {
+		return hook.PreUpdate()
+	}
+
+	return nil
+}
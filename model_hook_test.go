@@ -0,0 +1,170 @@
+package grocery
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type HookTestModel struct {
+	Base
+	Name string `grocery:"name"`
+
+	loaded int
+}
+
+func (m *HookTestModel) PreStore() error {
+	if m.Name == "invalid" {
+		return errors.New("invalid name")
+	}
+
+	return nil
+}
+
+func (m *HookTestModel) PreUpdate() error {
+	return m.PreStore()
+}
+
+func (m *HookTestModel) PostUpdate(pip redis.Pipeliner) {
+	pip.SAdd(ctx, "hookTestUpdated", m.Name)
+}
+
+func (m *HookTestModel) PreDelete() error {
+	if isMember, _ := C.SIsMember(ctx, "hookTestProtected", m.ID).Result(); isMember {
+		return errors.New("protected")
+	}
+
+	return nil
+}
+
+func (m *HookTestModel) PostDelete(pip redis.Pipeliner) {
+	pip.SAdd(ctx, "hookTestDeleted", m.ID)
+}
+
+func (m *HookTestModel) PostLoad() {
+	m.loaded++
+}
+
+type HookTestListModel struct {
+	Base
+	Models []*HookTestModel `grocery:"models"`
+}
+
+func TestPreStoreHook(t *testing.T) {
+	id := NewID()
+	err := StoreWithOptions(&HookTestModel{Name: "invalid"}, &StoreOptions{ID: id})
+
+	if err == nil {
+		t.Errorf("pre-store FAILED, expected an error")
+	}
+
+	if n, _ := C.Exists(ctx, "hooktestmodel:"+id).Result(); n != 0 {
+		t.Errorf("pre-store FAILED, expected object not to be stored")
+	}
+}
+
+func TestUpdateHooks(t *testing.T) {
+	id, _ := Store(&HookTestModel{Name: "valid"})
+
+	if err := Update(id, &HookTestModel{Name: "invalid"}); err == nil {
+		t.Errorf("pre-update FAILED, expected an error")
+	}
+
+	if err := Update(id, &HookTestModel{Name: "updated"}); err != nil {
+		t.Error(err)
+	}
+
+	if isMember, _ := C.SIsMember(ctx, "hookTestUpdated", "updated").Result(); !isMember {
+		t.Errorf("post-update FAILED, expected hook to be called")
+	}
+}
+
+func TestUpsertHooks(t *testing.T) {
+	id := NewID()
+
+	if err := StoreWithOptions(&HookTestModel{Name: "upsert created"}, &StoreOptions{ID: id, Upsert: true}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if isMember, _ := C.SIsMember(ctx, "hookTestUpdated", "upsert created").Result(); isMember {
+		t.Errorf("upsert FAILED, expected post-update hook not to be called on a new object")
+	}
+
+	// Upserting the existing object updates it
+	if err := StoreWithOptions(&HookTestModel{Name: "upsert updated"}, &StoreOptions{ID: id, Upsert: true}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if isMember, _ := C.SIsMember(ctx, "hookTestUpdated", "upsert updated").Result(); !isMember {
+		t.Errorf("upsert FAILED, expected post-update hook to be called on an existing object")
+	}
+}
+
+func TestDeleteHooks(t *testing.T) {
+	id, _ := Store(&HookTestModel{Name: "valid"})
+	C.SAdd(ctx, "hookTestProtected", id)
+
+	if err := Delete(id, new(HookTestModel)); err == nil {
+		t.Errorf("pre-delete FAILED, expected an error")
+	}
+
+	C.SRem(ctx, "hookTestProtected", id)
+
+	if err := Delete(id, new(HookTestModel)); err != nil {
+		t.Error(err)
+	}
+
+	if isMember, _ := C.SIsMember(ctx, "hookTestDeleted", id).Result(); !isMember {
+		t.Errorf("post-delete FAILED, expected hook to be called")
+	}
+}
+
+func TestPostLoadHook(t *testing.T) {
+	model := &HookTestModel{Name: "valid"}
+	Store(model)
+
+	id, _ := Store(&HookTestListModel{Models: []*HookTestModel{model}})
+
+	loaded := new(HookTestListModel)
+
+	if err := Load(id, loaded); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if loaded.Models[0].loaded != 1 {
+		t.Errorf("post-load FAILED, expected hook to be called on list references")
+	}
+
+	results, _ := LoadEach[HookTestModel]([]string{model.ID}, nil)
+
+	if results[0].Value.loaded != 1 {
+		t.Errorf("post-load FAILED, expected hook to be called by LoadEach")
+	}
+
+	// The loaded object's hook is called once, not again by Load itself
+	loadedModel := new(HookTestModel)
+
+	if err := Load(model.ID, loadedModel); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if loadedModel.loaded != 1 {
+		t.Errorf("post-load FAILED, expected hook to be called once by Load, got %d", loadedModel.loaded)
+	}
+
+	stored := &HookTestModel{Name: "valid"}
+
+	if err := StoreWithOptions(stored, &StoreOptions{ID: NewID(), Load: true}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if stored.loaded != 1 {
+		t.Errorf("post-load FAILED, expected hook to be called once by Store, got %d", stored.loaded)
+	}
+}
@@ -0,0 +1,141 @@
+package grocery
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Events that notifications are published for. See Notification.
+const (
+	// The object was stored for the first time.
+	EventCreated = "created"
+
+	// The object was updated, or stored over an existing object.
+	EventUpdated = "updated"
+
+	// The object was deleted.
+	EventDeleted = "deleted"
+)
+
+// Notification is published as JSON to the <struct name>:<id> channel when
+// an object is written with UpdateOptions.Notify, or deleted with
+// DeleteOptions.Notify:
+//
+//	{"event":"updated","type":"item","id":"asdf","fields":["cost"],"values":{"cost":4.5}}
+//
+// Subscribers may decode it with json.Unmarshal.
+type Notification struct {
+	// EventCreated, EventUpdated or EventDeleted.
+	Event string `json:"event"`
+
+	// The prefix of the object's type (e.g. 'item' from Item), and its ID.
+	Type string `json:"type"`
+	ID   string `json:"id"`
+
+	// The grocery keys of the fields that were written or removed. Fields
+	// are only listed for created and updated objects.
+	Fields []string `json:"fields,omitempty"`
+
+	// The values that were written, keyed by the fields' grocery keys, if
+	// UpdateOptions.NotifyValues is set. Removed fields have null values.
+	// References hold the IDs of the referenced models, times hold Unix
+	// timestamps, and maps, sets and lists hold their contents.
+	Values map[string]interface{} `json:"values,omitempty"`
+}
+
+// changes collects the fields written to an object, for its notification.
+// A nil *changes ignores everything added to it.
+type changes struct {
+	fields []string
+
+	// The values written, or nil if they aren't included.
+	values map[string]interface{}
+}
+
+// newChanges returns a collector for the changes made to an object written
+// with opts, or nil if no notification is published.
+func newChanges(opts *UpdateOptions) *changes {
+	if !opts.Notify {
+		return nil
+	}
+
+	c := &changes{}
+
+	if opts.NotifyValues {
+		c.values = make(map[string]interface{})
+	}
+
+	return c
+}
+
+// add records that the field with grocery key k was written with value, or
+// removed if value is nil.
+func (c *changes) add(k string, value interface{}) {
+	if c == nil {
+		return
+	}
+
+	c.fields = append(c.fields, k)
+
+	if c.values != nil {
+		c.values[k] = value
+	}
+}
+
+// addField records that the field f, holding v, was written.
+func (c *changes) addField(f *field, v reflect.Value) {
+	if c == nil {
+		return
+	} else if c.values == nil {
+		c.add(f.key, nil)
+		return
+	}
+
+	switch f.kind {
+	case kindTime:
+		c.add(f.key, v.Interface().(time.Time).Unix())
+	case kindValue:
+		c.add(f.key, encodeValue(v))
+	case kindMap:
+		m := make(map[string]interface{})
+
+		v.Interface().(mapStore).Range(func(key, value interface{}) bool {
+			m[fmt.Sprint(key)] = value
+			return true
+		})
+
+		c.add(f.key, m)
+	case kindSet:
+		members := make([]interface{}, 0)
+
+		v.Interface().(setStore).Range(func(key, value interface{}) bool {
+			members = append(members, key)
+			return true
+		})
+
+		c.add(f.key, members)
+	default:
+		c.add(f.key, v.Interface())
+	}
+}
+
+// queueNotify adds a command to pip that publishes a notification for the
+// object stored at prefix:id. c is nil for deleted objects.
+func queueNotify(pip redis.Pipeliner, prefix, id, event string, c *changes) {
+	n := Notification{
+		Event: event,
+		Type:  prefix,
+		ID:    id,
+	}
+
+	if c != nil {
+		n.Fields, n.Values = c.fields, c.values
+	}
+
+	payload, _ := json.Marshal(n)
+	pip.Publish(ctx, prefix+":"+id, payload)
+}
@@ -0,0 +1,105 @@
+package grocery
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type NotifyTestModel struct {
+	Base
+
+	Name  string  `grocery:"name"`
+	Price float64 `grocery:"price"`
+}
+
+func TestNotifications(t *testing.T) {
+	sub := C.PSubscribe(ctx, "notifytestmodel:*")
+	defer sub.Close()
+
+	// Wait for the subscription to be confirmed
+	if _, err := sub.Receive(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	receive := func() *Notification {
+		timeout, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		msg, err := sub.ReceiveMessage(timeout)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		n := new(Notification)
+
+		if err := json.Unmarshal([]byte(msg.Payload), n); err != nil {
+			t.Fatal(err)
+		}
+
+		return n
+	}
+
+	m := &NotifyTestModel{Name: "apple", Price: 1.5}
+	id := NewID()
+	err := StoreWithOptions(m, &StoreOptions{
+		ID:            id,
+		UpdateOptions: &UpdateOptions{Notify: true},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := receive(); n.Event != EventCreated || n.Type != "notifytestmodel" || n.ID != id {
+		t.Errorf("TestNotifications FAILED, expected created notification for %s, got %+v", id, n)
+	} else if len(n.Fields) != 2 || n.Values != nil {
+		t.Errorf("TestNotifications FAILED, expected name and price without values, got %+v", n)
+	}
+
+	err = UpdateWithOptions(id, &NotifyTestModel{Price: 2}, &UpdateOptions{
+		Notify:       true,
+		NotifyValues: true,
+		Unset:        []string{"name"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := receive()
+
+	if n.Event != EventUpdated || n.ID != id {
+		t.Errorf("TestNotifications FAILED, expected updated notification for %s, got %+v", id, n)
+	} else if price, ok := n.Values["price"]; !ok || price != 2.0 {
+		t.Errorf("TestNotifications FAILED, expected price 2, got %+v", n.Values)
+	} else if name, ok := n.Values["name"]; !ok || name != nil {
+		t.Errorf("TestNotifications FAILED, expected name to be removed, got %+v", n.Values)
+	}
+
+	loaded := new(NotifyTestModel)
+
+	if err := Load(id, loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded.Price = 3
+
+	if err := SaveWithOptions(loaded, &UpdateOptions{Notify: true, NotifyValues: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := receive(); n.Event != EventUpdated || len(n.Fields) != 1 || n.Values["price"] != 3.0 {
+		t.Errorf("TestNotifications FAILED, expected only price to be saved, got %+v", n)
+	}
+
+	if err := DeleteWithOptions(id, new(NotifyTestModel), &DeleteOptions{Notify: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := receive(); n.Event != EventDeleted || n.ID != id || n.Fields != nil {
+		t.Errorf("TestNotifications FAILED, expected deleted notification for %s, got %+v", id, n)
+	}
+}
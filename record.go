@@ -0,0 +1,65 @@
+package grocery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// Client whose pipelines record their commands instead of sending them to
+	// Redis. See record.
+	recorder     *redis.Client
+	recorderOnce sync.Once
+)
+
+type recordedCmdsKey struct{}
+
+// record returns a pipeline along with a function that returns the commands
+// queued to it. Recording commands lets grocery build up the commands for a
+// single object, and then decide whether to pass them on to another pipeline
+// once it knows they were all queued without errors. The pipeline must not be
+// executed.
+func record() (redis.Pipeliner, func() []redis.Cmder) {
+	recorderOnce.Do(func() {
+		recorder = redis.NewClient(&redis.Options{})
+		recorder.AddHook(recordHook{})
+	})
+
+	pip := recorder.Pipeline()
+
+	return pip, func() []redis.Cmder {
+		var cmds []redis.Cmder
+		pip.Exec(context.WithValue(ctx, recordedCmdsKey{}, &cmds))
+		return cmds
+	}
+}
+
+// recordHook stops the recorder's commands from reaching Redis, and hands
+// them back to record instead.
+type recordHook struct{}
+
+func (recordHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, errors.New("grocery: recorder must not connect to Redis")
+	}
+}
+
+func (recordHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		return errors.New("grocery: recorder only supports pipelines")
+	}
+}
+
+func (recordHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if recorded, ok := ctx.Value(recordedCmdsKey{}).(*[]redis.Cmder); ok {
+			*recorded = append(*recorded, cmds...)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,69 @@
+package grocery
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Model types that grocery knows about, keyed by prefix.
+var models sync.Map
+
+// Register makes grocery aware of the types of the given models, which are
+// pointers to structs like those passed to Store. Delete relies on knowing
+// every type with references to the object being deleted in order to enforce
+// their delete policies, and fails if it doesn't, so types that keep backrefs
+// should be registered when your program starts:
+//
+//	db.Register(new(Fruit), new(Basket))
+//
+// Types are also registered automatically the first time they're stored,
+// updated, loaded or deleted. An error is returned if a type's tags are
+// invalid, such as an unknown ondelete policy, in which case the type isn't
+// registered, and every operation on it fails with the same error.
+func Register(ptrs ...interface{}) error {
+	for _, ptr := range ptrs {
+		_, typ, err := structOf(ptr)
+
+		if err != nil {
+			return err
+		} else if err := register(typ); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// register makes grocery aware of the struct type typ, or returns the error
+// in its tags.
+func register(typ reflect.Type) error {
+	s := schemaOf(typ)
+
+	if s.err != nil {
+		return s.err
+	}
+
+	models.LoadOrStore(s.prefix, typ)
+	return nil
+}
+
+// referrersOf returns the registered model types that keep backrefs for
+// references to models with the given prefix.
+func referrersOf(refPrefix string) []reflect.Type {
+	var types []reflect.Type
+
+	models.Range(func(key, value interface{}) bool {
+		typ := value.(reflect.Type)
+
+		for _, br := range schemaOf(typ).backrefs {
+			if br.refPrefix == refPrefix {
+				types = append(types, typ)
+				break
+			}
+		}
+
+		return true
+	})
+
+	return types
+}
@@ -0,0 +1,432 @@
+package grocery
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var baseType = reflect.TypeOf(Base{})
+
+// snapshot holds the values of an object's fields as they were last loaded
+// from or written to Redis, so that Save can write only what has changed
+// since. Fields are keyed by their grocery keys, and zero values are left
+// out, since they aren't stored.
+type snapshot struct {
+	// Values stored in the object's hash, as they're passed to HSet. Single
+	// references hold the referenced model's ID.
+	values map[string]interface{}
+
+	// Lists of references or strings, in order.
+	lists map[string][]string
+
+	// Maps, and sets with empty values.
+	collections map[string]map[string]string
+}
+
+// baseOf returns the Base embedded in the struct val, or nil if it doesn't
+// embed one or can't be modified.
+func baseOf(val reflect.Value) *Base {
+	for _, f := range schemaOf(val.Type()).fields {
+		if f.kind == kindEmbedded && f.typ == baseType && val.CanAddr() {
+			return val.Field(f.index).Addr().Interface().(*Base)
+		}
+	}
+
+	return nil
+}
+
+// takeSnapshot returns the current values of the fields of val that Save
+// compares. It fails if val references a model that hasn't been stored.
+func takeSnapshot(val reflect.Value) (*snapshot, error) {
+	snap := &snapshot{
+		values:      make(map[string]interface{}),
+		lists:       make(map[string][]string),
+		collections: make(map[string]map[string]string),
+	}
+
+	for _, f := range schemaOf(val.Type()).fields {
+		structField := val.Field(f.index)
+		k := f.key
+
+		if f.immutable || (structField.Kind() == reflect.Ptr && structField.IsNil()) {
+			continue
+		}
+
+		switch f.kind {
+		case kindValue:
+			if !structField.IsZero() {
+				snap.values[k] = encodeValue(structField)
+			}
+		case kindTime:
+			if !structField.IsZero() {
+				snap.values[k] = structField.Interface().(time.Time).Unix()
+			}
+		case kindRef:
+			refID, ok, _ := referenceID(nil, structField, &UpdateOptions{})
+
+			if !ok {
+				return nil, fmt.Errorf("can't set unknown field '%s'", k)
+			}
+
+			snap.values[k] = refID
+		case kindRefList:
+			for i := 0; i < structField.Len(); i++ {
+				itemID, ok, _ := referenceID(nil, structField.Index(i), &UpdateOptions{})
+
+				if !ok {
+					return nil, fmt.Errorf("can't set unknown array item in %s", k)
+				}
+
+				snap.lists[k] = append(snap.lists[k], itemID)
+			}
+		case kindStringList:
+			for i := 0; i < structField.Len(); i++ {
+				snap.lists[k] = append(snap.lists[k], structField.Index(i).String())
+			}
+		case kindMap:
+			m := make(map[string]string)
+
+			structField.Interface().(mapStore).Range(func(key, value interface{}) bool {
+				m[fmt.Sprint(key)] = fmt.Sprint(value)
+				return true
+			})
+
+			snap.collections[k] = m
+		case kindSet:
+			m := make(map[string]string)
+
+			structField.Interface().(setStore).Range(func(key, value interface{}) bool {
+				m[fmt.Sprint(key)] = ""
+				return true
+			})
+
+			snap.collections[k] = m
+		}
+	}
+
+	return snap, nil
+}
+
+// keepSnapshot records the current values of the object in val as the ones
+// stored in Redis, if it embeds Base.
+func keepSnapshot(val reflect.Value) {
+	if base := baseOf(val); base != nil {
+		base.snapshot, _ = takeSnapshot(val)
+	}
+}
+
+// Save updates an object that was loaded or stored earlier, writing only the
+// fields that have changed since then. Unlike Update, fields that have been
+// set to zero values are removed from Redis, and maps, sets and lists are
+// updated with only the entries that were added or removed:
+//
+//	item := new(Item)
+//	db.Load(itemID, item)
+//
+//	item.Price = 0
+//	item.Tags.Add("sale")
+//
+//	// Removes price, and adds "sale" to the item's tags
+//	db.Save(item)
+//
+// ptr must embed Base and have an ID. If it wasn't loaded or stored by
+// grocery, Save behaves like Update, writing every non-zero field. Fields
+// that have been cleared are validated as if they were unset, so clearing a
+// required field fails with a ValidationError.
+//
+// A copy of an object, such as one made with item2 := *item, starts out with
+// the same remembered values as the original. Each copy then saves its own
+// changes since the copy was made, and saving one doesn't affect what the
+// other writes.
+func Save(ptr interface{}) error {
+	return SaveWithOptions(ptr, &UpdateOptions{})
+}
+
+// SaveWithOptions saves an object, like Save, but with options. SetZeroValues
+// is ignored, since fields set to zero values are always removed.
+func SaveWithOptions(ptr interface{}, opts *UpdateOptions) error {
+	if reflect.TypeOf(ptr).Kind() != reflect.Ptr || reflect.TypeOf(ptr).Elem().Kind() != reflect.Struct {
+		return errors.New("ptr must be a struct pointer")
+	}
+
+	val, typ := reflect.ValueOf(ptr).Elem(), reflect.TypeOf(ptr).Elem()
+	base := baseOf(val)
+
+	if base == nil || base.ID == "" {
+		return errors.New("ptr must have an ID")
+	}
+
+	if err := register(typ); err != nil {
+		return err
+	}
+
+	prefix, id := schemaOf(typ).prefix, base.ID
+
+	opts = copyOptions(opts)
+	op := startOperation(nil, "grocery.Save", prefix, id)
+	opts.ctx = op.ctx
+
+	if err := callPreWriteHook(ptr, false); err != nil {
+		return op.end(err)
+	}
+
+	cur, err := takeSnapshot(val)
+
+	if err != nil {
+		return op.end(err)
+	}
+
+	old := base.snapshot
+
+	if old == nil {
+		old = &snapshot{}
+	}
+
+	// Fields that have been cleared since the object was loaded are removed,
+	// so they're validated as if they had been unset
+	if err := validate(ptr, val, typ, &UpdateOptions{Unset: old.cleared(cur)}); err != nil {
+		return op.end(err)
+	}
+
+	err = writeChecked(prefix, id, ptr, typ, opts, func(pip redis.Pipeliner, stored *stored) error {
+		changed := queueSave(pip, prefix, id, typ, old, cur, stored.refs)
+		op.setFields(len(changed))
+
+		if len(changed) == 0 {
+			// Nothing has changed
+			return nil
+		}
+
+		pip.HSet(ctx, prefix+":"+id, "updatedAt", now().Unix())
+
+		if hook, ok := ptr.(PostUpdateHook); ok {
+			hook.PostUpdate(pip)
+		}
+
+		if written := newChanges(opts); written != nil {
+			for _, f := range changed {
+				written.add(f.key, cur.notifyValue(f))
+			}
+
+			queueNotify(pip, prefix, id, EventUpdated, written)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return op.end(err)
+	}
+
+	base.snapshot = cur
+	return op.end(nil)
+}
+
+// queueSave adds the commands that change the object stored at prefix:id from
+// old to cur to pip, and returns the fields that have changed. oldRefs
+// holds the IDs the object currently references through its backref fields,
+// from queueBackrefReads.
+func queueSave(pip redis.Pipeliner, prefix, id string, typ reflect.Type, old, cur *snapshot, oldRefs map[string][]string) []*field {
+	var changed []*field
+	key := prefix + ":" + id
+
+	for _, f := range schemaOf(typ).fields {
+		k := f.key
+
+		switch f.kind {
+		case kindValue, kindTime, kindRef:
+			value, ok := cur.values[k]
+
+			if oldValue, had := old.values[k]; ok && (!had || oldValue != value) {
+				pip.HSet(ctx, key, k, value)
+			} else if !ok && had {
+				pip.HDel(ctx, key, k)
+			} else {
+				continue
+			}
+
+			if f.backref {
+				var refIDs []string
+
+				if ok {
+					refIDs = []string{value.(string)}
+				}
+
+				queueBackrefMove(pip, f.refPrefix, prefix, id, oldRefs[k], refIDs)
+			}
+		case kindRefList, kindStringList:
+			if !queueListDelta(pip, key+":"+k, old.lists[k], cur.lists[k]) {
+				continue
+			}
+
+			if f.backref {
+				queueBackrefMove(pip, f.refPrefix, prefix, id, oldRefs[k], cur.lists[k])
+			}
+		case kindMap, kindSet:
+			if !queueCollectionDelta(pip, key+":"+k, f.kind == kindSet, old.collections[k], cur.collections[k]) {
+				continue
+			}
+		default:
+			continue
+		}
+
+		changed = append(changed, f)
+	}
+
+	return changed
+}
+
+// cleared returns the keys of the fields that are set in snap, but not in cur.
+func (snap *snapshot) cleared(cur *snapshot) []string {
+	var keys []string
+
+	for k := range snap.values {
+		if _, ok := cur.values[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	for k := range snap.lists {
+		if _, ok := cur.lists[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	for k := range snap.collections {
+		if _, ok := cur.collections[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// notifyValue returns the value of the field f in the snapshot, as it's
+// included in notifications, or nil if it isn't set.
+func (snap *snapshot) notifyValue(f *field) interface{} {
+	switch f.kind {
+	case kindRefList, kindStringList:
+		return snap.lists[f.key]
+	case kindMap:
+		if m, ok := snap.collections[f.key]; ok {
+			return m
+		}
+	case kindSet:
+		if set, ok := snap.collections[f.key]; ok {
+			members := make([]string, 0, len(set))
+
+			for member := range set {
+				members = append(members, member)
+			}
+
+			return members
+		}
+	default:
+		return snap.values[f.key]
+	}
+
+	return nil
+}
+
+// queueListDelta adds the commands that change the list at key from old to
+// cur to pip, and returns false if they're the same. Items appended to the
+// end of the list are pushed, and any other change rewrites the list.
+func queueListDelta(pip redis.Pipeliner, key string, old, cur []string) bool {
+	if len(cur) >= len(old) && equalStrings(old, cur[:len(old)]) {
+		if len(cur) == len(old) {
+			return false
+		}
+
+		pip.RPush(ctx, key, stringArgs(cur[len(old):])...)
+		return true
+	}
+
+	pip.Del(ctx, key)
+
+	if len(cur) > 0 {
+		pip.RPush(ctx, key, stringArgs(cur)...)
+	}
+
+	return true
+}
+
+// queueCollectionDelta adds the commands that change the map or set at key
+// from old to cur to pip, and returns false if they're the same.
+func queueCollectionDelta(pip redis.Pipeliner, key string, isSet bool, old, cur map[string]string) bool {
+	var added []interface{}
+	var removed []string
+	changed := false
+
+	for k, v := range cur {
+		if oldValue, ok := old[k]; ok && oldValue == v {
+			continue
+		} else if isSet {
+			added = append(added, k)
+		} else {
+			added = append(added, k, v)
+		}
+	}
+
+	for k := range old {
+		if _, ok := cur[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+
+	if len(removed) > 0 && len(removed) == len(old) && len(added) == 0 {
+		pip.Del(ctx, key)
+		return true
+	}
+
+	if len(removed) > 0 {
+		changed = true
+
+		if isSet {
+			pip.SRem(ctx, key, stringArgs(removed)...)
+		} else {
+			pip.HDel(ctx, key, removed...)
+		}
+	}
+
+	if len(added) > 0 {
+		changed = true
+
+		if isSet {
+			pip.SAdd(ctx, key, added...)
+		} else {
+			pip.HSet(ctx, key, added...)
+		}
+	}
+
+	return changed
+}
+
+// equalStrings returns true if a and b hold the same strings in order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stringArgs returns strs as arguments for a command.
+func stringArgs(strs []string) []interface{} {
+	args := make([]interface{}, len(strs))
+
+	for i, s := range strs {
+		args[i] = s
+	}
+
+	return args
+}
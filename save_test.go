@@ -0,0 +1,162 @@
+package grocery
+
+import (
+	"reflect"
+	"testing"
+)
+
+type SaveTestModel struct {
+	Base
+
+	Name  string         `grocery:"name"`
+	Price float64        `grocery:"price"`
+	Tags  []string       `grocery:"tags"`
+	Meta  *Map           `grocery:"meta"`
+	Set   *Set           `grocery:"set"`
+	Owner *SaveTestModel `grocery:"owner,backref"`
+}
+
+func TestSave(t *testing.T) {
+	owner := &SaveTestModel{Name: "owner"}
+	ownerID, _ := Store(owner)
+	other := &SaveTestModel{Name: "other"}
+	otherID, _ := Store(other)
+
+	id, err := Store(&SaveTestModel{
+		Name:  "before",
+		Price: 2.5,
+		Tags:  []string{"a", "b"},
+		Meta:  NewMap(map[string]string{"k": "v", "old": "x"}),
+		Set:   NewSet([]string{"a", "b"}),
+		Owner: owner,
+	})
+
+	if err != nil {
+		t.Fatalf("TestSave FAILED, got error %v", err)
+	}
+
+	m := new(SaveTestModel)
+	Load(id, m)
+
+	// Change values behind the loaded object's back, which Save must leave
+	// alone unless the fields they're in have changed
+	C.HSet(ctx, "savetestmodel:"+id, "name", "external")
+	C.SAdd(ctx, "savetestmodel:"+id+":set", "external")
+	C.HSet(ctx, "savetestmodel:"+id+":meta", "external", "y")
+
+	m.Price = 0
+	m.Tags = append(m.Tags, "c")
+	m.Meta.Delete("old")
+	m.Meta.Store("k", "changed")
+	m.Set.Delete("a")
+	m.Set.Add("c")
+	m.Owner = other
+
+	if err := Save(m); err != nil {
+		t.Fatalf("TestSave FAILED, got error %v", err)
+	}
+
+	key := "savetestmodel:" + id
+
+	if name := C.HGet(ctx, key, "name").Val(); name != "external" {
+		t.Errorf("TestSave FAILED, expected unchanged name to be left alone but got %s", name)
+	}
+
+	if C.HExists(ctx, key, "price").Val() {
+		t.Errorf("TestSave FAILED, expected cleared price to be removed")
+	}
+
+	if tags := C.LRange(ctx, key+":tags", 0, -1).Val(); !reflect.DeepEqual(tags, []string{"a", "b", "c"}) {
+		t.Errorf("TestSave FAILED, expected tags [a b c] but got %v", tags)
+	}
+
+	expectedMeta := map[string]string{"k": "changed", "external": "y"}
+
+	if meta := C.HGetAll(ctx, key+":meta").Val(); !reflect.DeepEqual(meta, expectedMeta) {
+		t.Errorf("TestSave FAILED, expected meta %v but got %v", expectedMeta, meta)
+	}
+
+	expectedSet := map[string]bool{"b": true, "c": true, "external": true}
+	set := C.SMembers(ctx, key+":set").Val()
+
+	if len(set) != len(expectedSet) {
+		t.Errorf("TestSave FAILED, expected set %v but got %v", expectedSet, set)
+	}
+
+	for _, member := range set {
+		if !expectedSet[member] {
+			t.Errorf("TestSave FAILED, expected set %v but got %v", expectedSet, set)
+		}
+	}
+
+	if C.SIsMember(ctx, backrefKey("savetestmodel", ownerID, "savetestmodel"), id).Val() {
+		t.Errorf("TestSave FAILED, expected backref to old owner to be removed")
+	} else if !C.SIsMember(ctx, backrefKey("savetestmodel", otherID, "savetestmodel"), id).Val() {
+		t.Errorf("TestSave FAILED, expected backref to new owner to be added")
+	}
+
+	// Saving again only writes what changed since the last save
+	C.HSet(ctx, key, "name", "external again")
+	m.Price = 1
+
+	if err := Save(m); err != nil {
+		t.Fatalf("TestSave FAILED, got error %v", err)
+	}
+
+	if name := C.HGet(ctx, key, "name").Val(); name != "external again" {
+		t.Errorf("TestSave FAILED, expected unchanged name to be left alone but got %s", name)
+	}
+
+	if price := C.HGet(ctx, key, "price").Val(); price != "1" {
+		t.Errorf("TestSave FAILED, expected price 1 but got %s", price)
+	}
+}
+
+func TestSaveWithoutID(t *testing.T) {
+	if err := Save(&SaveTestModel{Name: "new"}); err == nil {
+		t.Errorf("TestSaveWithoutID FAILED, expected error")
+	}
+}
+
+func TestSaveCopy(t *testing.T) {
+	id, err := Store(&SaveTestModel{Name: "before", Price: 1})
+
+	if err != nil {
+		t.Fatalf("TestSaveCopy FAILED, got error %v", err)
+	}
+
+	m := new(SaveTestModel)
+	Load(id, m)
+
+	copied := *m
+
+	m.Price = 2
+	copied.Name = "after"
+
+	if err := Save(m); err != nil {
+		t.Fatalf("TestSaveCopy FAILED, got error %v", err)
+	}
+
+	// The copy only writes its own change, rather than undoing the price
+	if err := Save(&copied); err != nil {
+		t.Fatalf("TestSaveCopy FAILED, got error %v", err)
+	}
+
+	loaded := new(SaveTestModel)
+	Load(id, loaded)
+
+	if loaded.Name != "after" || loaded.Price != 2 {
+		t.Errorf("TestSaveCopy FAILED, expected after and 2 but got %s and %v", loaded.Name, loaded.Price)
+	}
+
+	// Saving the original again doesn't write the copy's values back
+	C.HSet(ctx, "savetestmodel:"+id, "name", "external")
+
+	if err := Save(m); err != nil {
+		t.Fatalf("TestSaveCopy FAILED, got error %v", err)
+	}
+
+	if name := C.HGet(ctx, "savetestmodel:"+id, "name").Val(); name != "external" {
+		t.Errorf("TestSaveCopy FAILED, expected unchanged name to be left alone but got %s", name)
+	}
+}
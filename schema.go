@@ -0,0 +1,374 @@
+package grocery
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fieldKind describes how a field is stored in Redis.
+type fieldKind int
+
+const (
+	// An unsupported field, which fails when it's written or loaded.
+	kindUnsupported fieldKind = iota
+
+	// A number, string or bool, stored in the object's hash.
+	kindValue
+
+	// A pointer to a number, string or bool, which can only be loaded.
+	kindValuePtr
+
+	// A time.Time, stored in the object's hash as a Unix timestamp.
+	kindTime
+
+	// A bool with a Load method, which loads itself instead of being stored.
+	kindCustomBool
+
+	// A *Map or a custom map type, stored as a hash at prefix:id:key.
+	kindMap
+
+	// A *Set or a custom set type, stored as a set at prefix:id:key.
+	kindSet
+
+	// A pointer to another model, whose ID is stored in the object's hash.
+	kindRef
+
+	// A slice of pointers to other models, whose IDs are stored as a list at
+	// prefix:id:key.
+	kindRefList
+
+	// A slice of strings, stored as a list at prefix:id:key.
+	kindStringList
+
+	// A ModelHook, which isn't stored.
+	kindHook
+
+	// An embedded struct, whose fields are loaded as part of the object.
+	kindEmbedded
+)
+
+// schema is everything grocery needs to know about a model type to store and
+// load it, compiled once per type so that the struct's tags and fields don't
+// need to be inspected again on every call.
+type schema struct {
+	typ reflect.Type
+
+	// The prefix of the model's keys (e.g. 'item' from Item).
+	prefix string
+
+	// The model's stored fields, in the order they're declared.
+	fields []*field
+
+	// The fields that keep backrefs, and the keys of fields stored under
+	// their own keys, such as maps, sets and lists.
+	backrefs []backref
+	subKeys  []string
+
+	// Whether the model writes or reads its own fields. See Marshaler and
+	// Unmarshaler.
+	marshaler   bool
+	unmarshaler bool
+
+	// The error in the model's tags, if any, which every operation on the
+	// model fails with. See register.
+	err error
+}
+
+// field is a single field of a schema.
+type field struct {
+	// The field's index within its struct, its grocery key and the options
+	// that follow the key in its tag.
+	index int
+	key   string
+	opts  []string
+	kind  fieldKind
+	typ   reflect.Type
+
+	// Whether the field is embedded, and whether it may only be written by
+	// grocery itself.
+	anonymous bool
+	immutable bool
+
+	// The schema of an embedded struct.
+	embedded *schema
+
+	// The type of model a reference points to, and its prefix.
+	refType   reflect.Type
+	refPrefix string
+
+	// Whether the field keeps backrefs, and its delete policy.
+	backref  bool
+	onDelete string
+
+	// The field's validation rules.
+	rules []rule
+
+	// Why the field can't be written or loaded, for unsupported fields. An
+	// unsupported field without loadErr is loaded as a plain value.
+	writeErr string
+	loadErr  string
+}
+
+// Compiled schemas, keyed by reflect.Type.
+var schemas sync.Map
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf returns the compiled schema for the struct type typ.
+func schemaOf(typ reflect.Type) *schema {
+	if s, ok := schemas.Load(typ); ok {
+		return s.(*schema)
+	}
+
+	s, _ := schemas.LoadOrStore(typ, compileSchema(typ))
+	return s.(*schema)
+}
+
+func compileSchema(typ reflect.Type) *schema {
+	s := &schema{
+		typ:         typ,
+		prefix:      strings.ToLower(typ.Name()),
+		marshaler:   implementsOwn(typ, marshalerType),
+		unmarshaler: implementsOwn(typ, unmarshalerType),
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		typeField := typ.Field(i)
+		k, tagOpts := fieldKey(typeField)
+
+		if k == "-" || typeField.PkgPath != "" {
+			// Skip values that shouldn't be stored, and unexported fields
+			continue
+		}
+
+		f := &field{
+			index:     i,
+			key:       k,
+			opts:      tagOpts,
+			typ:       typeField.Type,
+			anonymous: typeField.Anonymous,
+			immutable: hasOption(tagOpts, "immutable"),
+			rules:     compileRules(tagOpts),
+		}
+
+		if typeField.Anonymous && typeField.Type.Kind() == reflect.Struct {
+			f.kind = kindEmbedded
+			f.embedded = schemaOf(typeField.Type)
+
+			if f.embedded.err != nil {
+				s.err = f.embedded.err
+			}
+		} else {
+			compileField(f)
+		}
+
+		if (f.kind == kindRef || f.kind == kindRefList) && !f.anonymous {
+			_, hasPolicy := optionValue(tagOpts, "ondelete")
+			f.backref = hasOption(tagOpts, "backref") || hasPolicy
+			f.onDelete, _ = optionValue(tagOpts, "ondelete")
+
+			switch f.onDelete {
+			case "", OnDeleteRestrict, OnDeleteCascade, OnDeleteSetNull:
+			default:
+				s.err = fmt.Errorf("unknown delete policy '%s' for field '%s'", f.onDelete, k)
+			}
+
+			if f.backref {
+				s.backrefs = append(s.backrefs, backref{
+					key:       k,
+					refPrefix: f.refPrefix,
+					list:      f.kind == kindRefList,
+					onDelete:  f.onDelete,
+				})
+			}
+		}
+
+		if !f.anonymous && (f.kind == kindMap || f.kind == kindSet || f.typ.Kind() == reflect.Slice) {
+			s.subKeys = append(s.subKeys, k)
+		}
+
+		s.fields = append(s.fields, f)
+	}
+
+	return s
+}
+
+// compileField sets the kind of a field that isn't embedded, along with any
+// details needed to store or load that kind.
+func compileField(f *field) {
+	typ := f.typ
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		elem := typ.Elem()
+
+		if typ == mapType || (elem.Kind() == reflect.Struct && hasField(elem, "CustomMapType")) {
+			f.kind = kindMap
+		} else if typ == setType || (elem.Kind() == reflect.Struct && hasField(elem, "CustomSetType")) {
+			f.kind = kindSet
+		} else if elem.Kind() == reflect.Struct && hasField(elem, "Base") {
+			f.kind = kindRef
+			f.refType = elem
+			f.refPrefix = strings.ToLower(elem.Name())
+		} else if elem.Kind() == reflect.Struct {
+			f.kind = kindUnsupported
+			f.writeErr = "can't set unknown field '%s'"
+			f.loadErr = "Can't set unsupported struct with key %s"
+		} else {
+			f.kind = kindValuePtr
+			f.writeErr = "can't set unknown field '%s'"
+		}
+	case reflect.Slice:
+		elem := typ.Elem()
+
+		if elem.Kind() == reflect.String {
+			f.kind = kindStringList
+		} else if elem.Kind() == reflect.Ptr && elem.Elem().Kind() == reflect.Struct && hasField(elem.Elem(), "Base") {
+			f.kind = kindRefList
+			f.refType = elem.Elem()
+			f.refPrefix = strings.ToLower(elem.Elem().Name())
+		} else {
+			f.kind = kindUnsupported
+			f.writeErr = "can't set unknown array item in %s"
+			f.loadErr = "can't set unsupported struct"
+		}
+	case reflect.Map:
+		f.kind = kindUnsupported
+		f.writeErr = "type of field '%s' must be changed to *grocery.Map"
+	case reflect.Struct:
+		if typ == timeType {
+			f.kind = kindTime
+		} else {
+			f.kind = kindUnsupported
+			f.writeErr = "can't set unknown struct for field '%s'"
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		f.kind = kindValue
+	case reflect.Bool:
+		if reflect.PtrTo(typ).Implements(customBoolType) {
+			f.kind = kindCustomBool
+		} else {
+			f.kind = kindValue
+		}
+	case reflect.Interface:
+		if typ.Name() == "ModelHook" {
+			f.kind = kindHook
+		} else {
+			f.kind = kindUnsupported
+			f.writeErr = "don't know how to set interface field '%s'"
+		}
+	default:
+		f.kind = kindUnsupported
+		f.writeErr = "don't know how to set field '%s'"
+	}
+}
+
+// hasField returns true if the struct type typ has a field with the given
+// name, including promoted fields.
+func hasField(typ reflect.Type, name string) bool {
+	_, ok := typ.FieldByName(name)
+	return ok
+}
+
+// customBool is implemented by bool types that load their own values, rather
+// than being stored in the object's hash. It's called with the ID of the
+// object being loaded and the field's grocery key.
+type customBool interface {
+	Load(id, key string) (bool, error)
+}
+
+var customBoolType = reflect.TypeOf((*customBool)(nil)).Elem()
+
+// rule is a validation rule from a field's tag. See ValidationError.
+type rule struct {
+	name string
+	arg  string
+
+	// The bound of a min, max or len rule.
+	bound float64
+
+	// The pattern of a regex rule.
+	re *regexp.Regexp
+
+	// Why the rule itself is invalid, if it is.
+	invalid string
+}
+
+// compileRules returns the validation rules among the options of a grocery
+// tag.
+func compileRules(tagOpts []string) []rule {
+	var rules []rule
+
+	for _, opt := range tagOpts {
+		name, arg, _ := strings.Cut(opt, "=")
+		r := rule{name: name, arg: arg}
+
+		switch name {
+		case "required", "oneof":
+		case "min", "max", "len":
+			bound, err := strconv.ParseFloat(arg, 64)
+
+			if err != nil {
+				r.invalid = fmt.Sprintf("has an invalid %s rule '%s'", name, arg)
+			}
+
+			r.bound = bound
+		case "regex":
+			re, err := regexp.Compile(arg)
+
+			if err != nil {
+				r.invalid = fmt.Sprintf("has an invalid regex rule '%s'", arg)
+			}
+
+			r.re = re
+		default:
+			// Not a validation rule
+			continue
+		}
+
+		rules = append(rules, r)
+	}
+
+	return rules
+}
+
+// mapStore is implemented by *Map, and by custom map types that embed it.
+type mapStore interface {
+	Store(key, value any)
+	Range(f func(key, value any) bool)
+}
+
+// setStore is implemented by *Set, and by custom set types that embed it.
+type setStore interface {
+	Add(k any)
+	Range(f func(key, value any) bool)
+}
+
+// setupper is implemented by custom map and set types. See CustomMapType.
+type setupper interface {
+	Setup()
+}
+
+// encodeValue returns the value of a number, string or bool field as it's
+// passed to HSet. Alias types are converted to their underlying types.
+func encodeValue(field reflect.Value) interface{} {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(field.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return field.Float()
+	case reflect.Bool:
+		return field.Bool()
+	default:
+		return field.String()
+	}
+}
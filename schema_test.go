@@ -0,0 +1,122 @@
+package grocery
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+type SchemaTestModel struct {
+	Base
+
+	Name     string              `grocery:"name"`
+	Tags     []string            `grocery:"tags"`
+	Supplier *SchemaTestSupplier `grocery:"supplier,backref"`
+	Hidden   string              `grocery:"-"`
+	internal string
+}
+
+type SchemaTestSupplier struct {
+	Base
+
+	Name string `grocery:"name"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	typ := reflect.TypeOf(SchemaTestModel{})
+	s := schemaOf(typ)
+
+	if s != schemaOf(typ) {
+		t.Errorf("TestSchemaOf FAILED, expected schema to be cached")
+	}
+
+	if s.prefix != "schematestmodel" {
+		t.Errorf("TestSchemaOf FAILED, expected prefix schematestmodel but got %s", s.prefix)
+	}
+
+	kinds := map[string]fieldKind{}
+
+	for _, f := range s.fields {
+		kinds[f.key] = f.kind
+	}
+
+	expected := map[string]fieldKind{
+		"base":     kindEmbedded,
+		"name":     kindValue,
+		"tags":     kindStringList,
+		"supplier": kindRef,
+	}
+
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("TestSchemaOf FAILED, expected fields %v but got %v", expected, kinds)
+	}
+
+	if len(s.backrefs) != 1 || s.backrefs[0].refPrefix != "schematestsupplier" {
+		t.Errorf("TestSchemaOf FAILED, expected a backref to schematestsupplier but got %v", s.backrefs)
+	}
+
+	if !reflect.DeepEqual(s.subKeys, []string{"tags"}) {
+		t.Errorf("TestSchemaOf FAILED, expected sub keys [tags] but got %v", s.subKeys)
+	}
+}
+
+func TestStoreStringList(t *testing.T) {
+	id, err := Store(&SchemaTestModel{Name: "list", Tags: []string{"a", "b"}})
+
+	if err != nil {
+		t.Fatalf("TestStoreStringList FAILED, got error %v", err)
+	}
+
+	loaded := new(SchemaTestModel)
+
+	if err := Load(id, loaded); err != nil {
+		t.Fatalf("TestStoreStringList FAILED, got error %v", err)
+	}
+
+	if !reflect.DeepEqual(loaded.Tags, []string{"a", "b"}) {
+		t.Errorf("TestStoreStringList FAILED, expected [a b] but got %v", loaded.Tags)
+	}
+
+	if tags, _ := C.LRange(ctx, "schematestmodel:"+id+":tags", 0, -1).Result(); !reflect.DeepEqual(tags, []string{"a", "b"}) {
+		t.Errorf("TestStoreStringList FAILED, expected list [a b] but got %v", tags)
+	}
+
+	// Updating the slice replaces the list rather than appending to it
+	if err := Update(id, &SchemaTestModel{Name: "list", Tags: []string{"c"}}); err != nil {
+		t.Fatalf("TestStoreStringList FAILED, got error %v", err)
+	}
+
+	loaded = new(SchemaTestModel)
+
+	if err := Load(id, loaded); err != nil {
+		t.Fatalf("TestStoreStringList FAILED, got error %v", err)
+	}
+
+	if !reflect.DeepEqual(loaded.Tags, []string{"c"}) {
+		t.Errorf("TestStoreStringList FAILED, expected [c] but got %v", loaded.Tags)
+	}
+}
+
+type UnsignedTestModel struct {
+	Base
+
+	Count uint64 `grocery:"count"`
+}
+
+func TestStoreUnsigned(t *testing.T) {
+	id, err := Store(&UnsignedTestModel{Count: math.MaxUint64})
+
+	if err != nil {
+		t.Fatalf("TestStoreUnsigned FAILED, got error %v", err)
+	}
+
+	loaded := new(UnsignedTestModel)
+
+	if err := Load(id, loaded); err != nil {
+		t.Fatalf("TestStoreUnsigned FAILED, got error %v", err)
+	}
+
+	if loaded.Count != math.MaxUint64 {
+		t.Errorf("TestStoreUnsigned FAILED, expected %d but got %d", uint64(math.MaxUint64), loaded.Count)
+	}
+}
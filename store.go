@@ -2,57 +2,100 @@ package grocery
 
 import (
 	"reflect"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// NewID generates the IDs of objects that are stored without one. It creates
+// random UUIDs by default, and may be replaced to generate IDs differently.
+var NewID = uuid.NewString
+
 // StoreOptions provides options that may be passed to StoreWithOptions if the
 // default behavior of Store needs to be changed.
 type StoreOptions struct {
 	// ID is the ID this object must be stored with. Store will fail if an
 	// object with the type of the pointer being passed already exists with
-	// this ID, unless Overwrite is set to true.
+	// this ID, unless Upsert or Replace is set to true.
 	ID string
 
 	// Set to true if you would like to load the object from Redis back into
 	// the pointer after storing it.
 	Load bool
 
-	// Set to true if you would like to overwrite existing objects stored with
-	// this ID.
+	// Set to true if you would like an existing object stored with this ID to
+	// be updated instead, as with Update. The existing object's createdAt
+	// timestamp is kept, and its PreUpdate and PostUpdate hooks are called
+	// instead of PreStore and PostStore.
+	Upsert bool
+
+	// Set to true if you would like an existing object stored with this ID to
+	// be replaced. Unlike Upsert, the existing object's fields, maps, sets
+	// and lists are removed if they aren't set on the new object. Its
+	// createdAt timestamp is kept, and its update hooks are called, as with
+	// Upsert.
+	Replace bool
+
+	// Deprecated: Overwrite is the same as Upsert.
 	Overwrite bool
 
+	// CreatedAt is the createdAt timestamp this object must be stored with,
+	// such as when backfilling historical records. If it's zero, the current
+	// time is used, according to the clock set with SetClock. When upserting
+	// or replacing an existing object, CreatedAt replaces its timestamp.
+	CreatedAt time.Time
+
+	// Set to true if you would like referenced objects that haven't been
+	// stored yet, in single references or in lists, to be stored along with
+	// this one. They're stored in the same transaction, before this object's
+	// references to them are written, and their new IDs are set on them.
+	Cascade bool
+
 	// All other options inherit from UpdateOptions.
 	*UpdateOptions
 }
 
 // Store saves an object in Redis. As with all other Grocery operations, the
 // name of the pointer's struct type is used as a prefix for the object's key.
-// The object's ID is then randomly generated, and the object is stored at
+// The object's ID is then generated with NewID, and the object is stored at
 // prefix:id. If you would like to set a specific ID, use StoreWithOptions.
+// Maps, sets, string slices and slices of references are stored at their own
+// keys, at prefix:id:key, and string slices are stored as lists in order.
 func Store(ptr interface{}) (string, error) {
-	id := uuid.NewString()
-	return id, StoreWithOptions(ptr, &StoreOptions{id, false, false, nil})
+	id := NewID()
+	return id, StoreWithOptions(ptr, &StoreOptions{ID: id})
 }
 
 // StoreWithOptions saves an object in Redis, like Store, but with options.
 func StoreWithOptions(ptr interface{}, opts *StoreOptions) error {
-	if opts.UpdateOptions == nil {
-		opts.UpdateOptions = &UpdateOptions{}
+	update := copyOptions(opts.UpdateOptions)
+	update.isStore = true
+	update.storeOverwrite = opts.Upsert || opts.Replace || opts.Overwrite
+	update.storeReplace = opts.Replace
+	update.createdAt = opts.CreatedAt
+	update.cascade = opts.Cascade
+
+	if opts.Cascade {
+		update.cascaded = make(map[interface{}]string)
 	}
 
-	opts.UpdateOptions.isStore = true
-	opts.UpdateOptions.storeOverwrite = opts.Overwrite
+	op := startOperation(nil, "grocery.Store", prefixOf(ptr), opts.ID)
+	update.ctx = op.ctx
 
-	if err := updateInternal(opts.ID, ptr, opts.UpdateOptions); err != nil {
-		return err
+	err := updateInternal(opts.ID, ptr, update)
+	op.setFields(update.written)
+
+	if err != nil {
+		// Referenced objects weren't stored either, so clear their IDs
+		resetCascade(ptr, update)
+		return op.end(err)
 	}
 
 	if reflect.TypeOf(ptr).Kind() == reflect.Ptr {
 		if opts.Load {
 			// Load object back into the pointer
-			if err := Load(opts.ID, ptr); err != nil {
-				return err
+			if err := LoadWithOptions(opts.ID, ptr, &LoadOptions{ctx: op.ctx}); err != nil {
+				return op.end(err)
 			}
 		} else {
 			// Set ID
@@ -61,8 +104,10 @@ func StoreWithOptions(ptr interface{}, opts *StoreOptions) error {
 			if fi.String() != opts.ID {
 				fi.SetString(opts.ID)
 			}
+
+			keepSnapshot(reflect.ValueOf(ptr).Elem())
 		}
 	}
 
-	return nil
+	return op.end(nil)
 }
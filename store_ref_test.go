@@ -1,6 +1,7 @@
 package grocery
 
 import (
+	"sync/atomic"
 	"testing"
 )
 
@@ -74,3 +75,221 @@ func TestStoreListReference(t *testing.T) {
 		t.Errorf("ref name FAILED, expected %s but got %s", a.Name, loadedC.As[0].Name)
 	}
 }
+
+type CycleTest struct {
+	Base
+	Name string     `grocery:"name"`
+	Next *CycleTest `grocery:"next"`
+}
+
+func TestLoadCyclicReference(t *testing.T) {
+	a := &CycleTest{Name: "a"}
+	aID, _ := Store(a)
+
+	b := &CycleTest{Name: "b", Next: a}
+	bID, _ := Store(b)
+
+	// Point a back at b
+	if err := Update(aID, &CycleTest{Next: b}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	loadedA := new(CycleTest)
+
+	if err := Load(aID, loadedA); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if loadedA.Next == nil || loadedA.Next.ID != bID {
+		t.Errorf("cycle FAILED, expected next to be %s", bID)
+		return
+	}
+
+	if loadedA.Next.Next != loadedA {
+		t.Errorf("cycle FAILED, expected b.next to reuse the loaded instance of a")
+	}
+}
+
+type MissingRefTest struct {
+	Base
+	First *A `grocery:"first"`
+	Other *B `grocery:"other"`
+}
+
+func TestLoadMissingReference(t *testing.T) {
+	a := &A{Name: "bob"}
+	aID, _ := Store(a)
+
+	b := &B{A: a}
+	Store(b)
+
+	m := &MissingRefTest{First: a, Other: b}
+	mID, _ := Store(m)
+
+	// Remove a without clearing the references to it
+	C.Del(ctx, "a:"+aID)
+
+	loaded := new(MissingRefTest)
+
+	if err := Load(mID, loaded); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if loaded.First != nil {
+		t.Errorf("missing reference FAILED, expected first to be nil but got %+v", loaded.First)
+	}
+
+	if loaded.Other == nil || loaded.Other.A != nil {
+		t.Errorf("missing reference FAILED, expected other.ref to be nil like first")
+	}
+}
+
+func TestLoadMaxDepth(t *testing.T) {
+	a := &CycleTest{Name: "a"}
+	Store(a)
+
+	b := &CycleTest{Name: "b", Next: a}
+	Store(b)
+
+	c := &CycleTest{Name: "c", Next: b}
+	cID, _ := Store(c)
+
+	loadedC := new(CycleTest)
+
+	if err := LoadWithOptions(cID, loadedC, &LoadOptions{MaxDepth: 1}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if loadedC.Next.Name != b.Name {
+		t.Errorf("max depth FAILED, expected %s but got %s", b.Name, loadedC.Next.Name)
+	}
+
+	if loadedC.Next.Next.ID != a.ID {
+		t.Errorf("max depth stub FAILED, expected %s but got %s", a.ID, loadedC.Next.Next.ID)
+	}
+
+	if loadedC.Next.Next.Name != "" {
+		t.Errorf("max depth stub FAILED, expected name to be empty but got %s", loadedC.Next.Next.Name)
+	}
+}
+
+func TestLoadInclude(t *testing.T) {
+	a := &A{Name: "bob"}
+	aID, _ := Store(a)
+
+	b := &B{A: a}
+	bID, _ := Store(b)
+
+	c := &Ctest{As: []*A{a}}
+	cID, _ := Store(c)
+
+	loadedB := new(B)
+	LoadWithOptions(bID, loadedB, &LoadOptions{Include: []string{}})
+
+	if loadedB.A.ID != aID || loadedB.A.Name != "" {
+		t.Errorf("include stub FAILED, expected only ID %s to be set", aID)
+	}
+
+	loadedC := new(Ctest)
+	LoadWithOptions(cID, loadedC, &LoadOptions{Include: []string{"arrRef"}})
+
+	if loadedC.As[0].Name != a.Name {
+		t.Errorf("include FAILED, expected %s but got %s", a.Name, loadedC.As[0].Name)
+	}
+}
+
+func TestLoadListReferenceRoundTrips(t *testing.T) {
+	countRoundTrips := func(n int) int64 {
+		c := &Ctest{As: make([]*A, n)}
+
+		for i := range c.As {
+			c.As[i] = &A{Name: "bob"}
+			Store(c.As[i])
+		}
+
+		cID, _ := Store(c)
+		loadedC := new(Ctest)
+
+		before := atomic.LoadInt64(&roundTrips)
+
+		if err := Load(cID, loadedC); err != nil {
+			t.Error(err)
+		}
+
+		if len(loadedC.As) != n {
+			t.Errorf("round trips FAILED, expected %d references but got %d", n, len(loadedC.As))
+		}
+
+		return atomic.LoadInt64(&roundTrips) - before
+	}
+
+	few := countRoundTrips(2)
+	many := countRoundTrips(100)
+
+	if few != many {
+		t.Errorf("round trips FAILED, expected %d round trips but got %d", few, many)
+	}
+}
+
+func TestStoreCascade(t *testing.T) {
+	b := &B{A: &A{Name: "alice"}}
+	bID := NewID()
+
+	if err := StoreWithOptions(b, &StoreOptions{ID: bID, Cascade: true}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if b.A.ID == "" {
+		t.Errorf("cascade ID FAILED, expected referenced object to have an ID")
+	}
+
+	loadedB := new(B)
+	Load(bID, loadedB)
+
+	if loadedB.A == nil || loadedB.A.Name != b.A.Name {
+		t.Errorf("cascade FAILED, expected referenced object to be stored")
+	}
+
+	c := &Ctest{As: []*A{{Name: "x"}, {Name: "y"}}}
+	cID := NewID()
+
+	if err := StoreWithOptions(c, &StoreOptions{ID: cID, Cascade: true}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	loadedC := new(Ctest)
+	Load(cID, loadedC)
+
+	if len(loadedC.As) != 2 || loadedC.As[1].Name != "y" || loadedC.As[1].ID != c.As[1].ID {
+		t.Errorf("cascade list FAILED, expected referenced objects to be stored")
+	}
+
+	if err := StoreWithOptions(&B{A: &A{Name: "bob"}}, &StoreOptions{ID: NewID()}); err == nil {
+		t.Errorf("cascade FAILED, expected an error without Cascade")
+	}
+}
+
+func TestStoreCascadeCycle(t *testing.T) {
+	a := &CycleTest{Name: "a"}
+	b := &CycleTest{Name: "b", Next: a}
+	a.Next = b
+	aID := NewID()
+
+	if err := StoreWithOptions(a, &StoreOptions{ID: aID, Cascade: true}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	loadedA := new(CycleTest)
+	Load(aID, loadedA)
+
+	if loadedA.Next == nil || loadedA.Next.Name != "b" || loadedA.Next.Next != loadedA {
+		t.Errorf("cascade cycle FAILED, expected a and b to reference each other")
+	}
+}
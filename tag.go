@@ -0,0 +1,48 @@
+package grocery
+
+import (
+	"reflect"
+	"strings"
+)
+
+// fieldKey returns the key that field is stored with in Redis, along with the
+// options listed after the key in its grocery tag, such as "immutable". The
+// key is "-" if the field shouldn't be stored.
+func fieldKey(field reflect.StructField) (string, []string) {
+	parts := strings.Split(field.Tag.Get("grocery"), ",")
+	key, opts := parts[0], parts[1:]
+
+	if key == "" {
+		// Tag was not specified, assume field name
+		if len(field.Name) > 1 {
+			key = strings.ToLower(string(field.Name[0])) + string(field.Name[1:])
+		} else {
+			key = strings.ToLower(field.Name)
+		}
+	}
+
+	return key, opts
+}
+
+// hasOption returns true if option is one of the options in a grocery tag.
+func hasOption(opts []string, option string) bool {
+	for _, opt := range opts {
+		if opt == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// optionValue returns the value of an option in a grocery tag that's written
+// as name=value, such as "ondelete=cascade".
+func optionValue(opts []string, name string) (string, bool) {
+	for _, opt := range opts {
+		if strings.HasPrefix(opt, name+"=") {
+			return strings.TrimPrefix(opt, name+"="), true
+		}
+	}
+
+	return "", false
+}
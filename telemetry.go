@@ -0,0 +1,187 @@
+package grocery
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The name grocery's tracer and meter are created with.
+const instrumentationName = "github.com/nytimes/grocery"
+
+// TelemetryOptions enables OpenTelemetry instrumentation, and may be passed
+// to InitWithOptions as InitOptions.Telemetry. Each Store, StoreAll, Update,
+// UpdateAll, Save, Unset, Delete, Load, LoadAll, LoadEach and LoadReferrers
+// call is traced with a span, which has these attributes:
+//
+//	grocery.model       the prefix of the object's type (e.g. 'item')
+//	grocery.id          the object's ID, for operations on a single object
+//	grocery.fields      the number of fields that were read or written
+//	grocery.references  the number of referenced objects that were loaded
+//
+// Each level of references that's loaded is traced with a child span named
+// grocery.LoadReferences. The Redis commands run by an operation are passed
+// the context of its span, so that spans created by go-redis hooks, such as
+// the ones added by redisotel, are nested under it.
+//
+// The duration of each operation is recorded, in seconds, in the
+// grocery.operation.duration histogram, and operations that fail are counted
+// by grocery.operation.errors. Both have grocery.operation and grocery.model
+// attributes.
+type TelemetryOptions struct {
+	// TracerProvider creates the tracer that spans are started with.
+	// Defaults to the global provider, from otel.GetTracerProvider.
+	TracerProvider trace.TracerProvider
+
+	// MeterProvider creates the meter that metrics are recorded with.
+	// Defaults to the global provider, from otel.GetMeterProvider.
+	MeterProvider metric.MeterProvider
+}
+
+// The instruments used by grocery, stored as a *telemetry.
+var instruments atomic.Value
+
+// telemetry holds the instruments created from TelemetryOptions. Its fields
+// are nil if instrumentation isn't enabled.
+type telemetry struct {
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	errors   metric.Int64Counter
+}
+
+// newTelemetry creates the instruments described by opts, or ones that
+// disable instrumentation if opts is nil. They're only used once they're
+// stored in instruments.
+func newTelemetry(opts *TelemetryOptions) (*telemetry, error) {
+	if opts == nil {
+		return &telemetry{}, nil
+	}
+
+	tracerProvider, meterProvider := opts.TracerProvider, opts.MeterProvider
+
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+
+	meter := meterProvider.Meter(instrumentationName)
+	duration, err := meter.Float64Histogram("grocery.operation.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of grocery operations"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	failures, err := meter.Int64Counter("grocery.operation.errors",
+		metric.WithDescription("Number of grocery operations that failed"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &telemetry{
+		tracer:   tracerProvider.Tracer(instrumentationName),
+		duration: duration,
+		errors:   failures,
+	}, nil
+}
+
+// operation is a single traced grocery operation, such as a Load.
+type operation struct {
+	// Context for the Redis commands run by the operation, which holds its
+	// span.
+	ctx context.Context
+
+	t     *telemetry
+	span  trace.Span
+	name  string
+	model string
+	start time.Time
+}
+
+// startOperation starts the operation called name (e.g. "grocery.Load") on
+// objects with the given prefix, or on prefix:id if id isn't empty. parent is
+// the context of the operation it's part of, or nil. The operation must be
+// ended with end. If instrumentation isn't enabled, the operation only holds
+// a context.
+func startOperation(parent context.Context, name, prefix, id string) *operation {
+	if parent == nil {
+		parent = ctx
+	}
+
+	op := &operation{ctx: parent, name: name, model: prefix}
+	op.t, _ = instruments.Load().(*telemetry)
+
+	if op.t == nil || op.t.tracer == nil {
+		return op
+	}
+
+	var attrs []attribute.KeyValue
+
+	if prefix != "" {
+		attrs = append(attrs, attribute.String("grocery.model", prefix))
+	}
+
+	if id != "" {
+		attrs = append(attrs, attribute.String("grocery.id", id))
+	}
+
+	op.ctx, op.span = op.t.tracer.Start(parent, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+
+	op.start = time.Now()
+	return op
+}
+
+// setFields records the number of fields read or written by the operation.
+func (op *operation) setFields(n int) {
+	if op.span != nil {
+		op.span.SetAttributes(attribute.Int("grocery.fields", n))
+	}
+}
+
+// setReferences records the number of referenced objects loaded by the
+// operation.
+func (op *operation) setReferences(n int) {
+	if op.span != nil {
+		op.span.SetAttributes(attribute.Int("grocery.references", n))
+	}
+}
+
+// end ends the operation's span and records its duration. err is the error
+// the operation returned, if any.
+func (op *operation) end(err error) error {
+	if op.span == nil {
+		return err
+	}
+
+	if err != nil {
+		op.span.RecordError(err)
+		op.span.SetStatus(codes.Error, err.Error())
+	}
+
+	op.span.End()
+
+	attrs := metric.WithAttributes(
+		attribute.String("grocery.operation", op.name),
+		attribute.String("grocery.model", op.model))
+
+	op.t.duration.Record(op.ctx, time.Since(op.start).Seconds(), attrs)
+
+	if err != nil {
+		op.t.errors.Add(op.ctx, 1, attrs)
+	}
+
+	return err
+}
@@ -0,0 +1,414 @@
+package grocery
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type TelemetryTestOwner struct {
+	Base
+
+	Name string `grocery:"name"`
+}
+
+type TelemetryTestSupplier struct {
+	Base
+
+	Name  string              `grocery:"name"`
+	Owner *TelemetryTestOwner `grocery:"owner"`
+}
+
+type TelemetryTestModel struct {
+	Base
+
+	Name     string                 `grocery:"name"`
+	Supplier *TelemetryTestSupplier `grocery:"supplier,backref"`
+}
+
+// testTracer records the spans grocery starts, as an OpenTelemetry SDK would,
+// without depending on one.
+type testTracer struct {
+	mux   sync.Mutex
+	spans []*testSpan
+}
+
+func (tr *testTracer) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return tr
+}
+
+func (tr *testTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	tr.mux.Lock()
+	defer tr.mux.Unlock()
+
+	var spanID trace.SpanID
+	binary.BigEndian.PutUint64(spanID[:], uint64(len(tr.spans)+1))
+
+	span := &testSpan{
+		tracer: tr,
+		name:   name,
+		parent: trace.SpanContextFromContext(ctx).SpanID(),
+		attrs:  make(map[attribute.Key]attribute.Value),
+		sc: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{1},
+			SpanID:     spanID,
+			TraceFlags: trace.FlagsSampled,
+		}),
+	}
+
+	config := trace.NewSpanStartConfig(opts...)
+
+	for _, kv := range config.Attributes() {
+		span.attrs[kv.Key] = kv.Value
+	}
+
+	tr.spans = append(tr.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+// ended returns the spans that have ended, keyed by their names.
+func (tr *testTracer) ended() map[string][]*testSpan {
+	tr.mux.Lock()
+	defer tr.mux.Unlock()
+
+	byName := make(map[string][]*testSpan)
+
+	for _, span := range tr.spans {
+		if span.ended {
+			byName[span.name] = append(byName[span.name], span)
+		}
+	}
+
+	return byName
+}
+
+type testSpan struct {
+	tracer *testTracer
+	name   string
+	parent trace.SpanID
+	sc     trace.SpanContext
+	attrs  map[attribute.Key]attribute.Value
+	status codes.Code
+	ended  bool
+}
+
+func (s *testSpan) End(...trace.SpanEndOption) {
+	s.tracer.mux.Lock()
+	defer s.tracer.mux.Unlock()
+
+	s.ended = true
+}
+
+func (s *testSpan) AddEvent(string, ...trace.EventOption)   {}
+func (s *testSpan) IsRecording() bool                       { return true }
+func (s *testSpan) RecordError(error, ...trace.EventOption) {}
+func (s *testSpan) SpanContext() trace.SpanContext          { return s.sc }
+func (s *testSpan) SetName(name string)                     {}
+func (s *testSpan) TracerProvider() trace.TracerProvider    { return s.tracer }
+func (s *testSpan) SetStatus(code codes.Code, _ string)     { s.status = code }
+
+func (s *testSpan) SetAttributes(kv ...attribute.KeyValue) {
+	s.tracer.mux.Lock()
+	defer s.tracer.mux.Unlock()
+
+	for _, attr := range kv {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+// testMeterProvider provides a testMeter.
+type testMeterProvider struct {
+	noop.MeterProvider
+	meter *testMeter
+}
+
+func (p testMeterProvider) Meter(string, ...metric.MeterOption) metric.Meter {
+	return p.meter
+}
+
+// testMeter counts the durations and failures grocery records by operation.
+type testMeter struct {
+	noop.Meter
+
+	mux       sync.Mutex
+	durations map[string]int
+	failures  int64
+}
+
+func (m *testMeter) Float64Histogram(string, ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return testHistogram{m: m}, nil
+}
+
+func (m *testMeter) Int64Counter(string, ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return testCounter{m: m}, nil
+}
+
+type testHistogram struct {
+	noop.Float64Histogram
+	m *testMeter
+}
+
+func (h testHistogram) Record(_ context.Context, _ float64, opts ...metric.RecordOption) {
+	attrs := metric.NewRecordConfig(opts).Attributes()
+	op, _ := attrs.Value("grocery.operation")
+
+	h.m.mux.Lock()
+	defer h.m.mux.Unlock()
+
+	h.m.durations[op.AsString()]++
+}
+
+type testCounter struct {
+	noop.Int64Counter
+	m *testMeter
+}
+
+func (c testCounter) Add(_ context.Context, incr int64, _ ...metric.AddOption) {
+	c.m.mux.Lock()
+	defer c.m.mux.Unlock()
+
+	c.m.failures += incr
+}
+
+// spanHook records the spans that Redis commands are run in, as go-redis
+// hooks such as redisotel see them.
+type spanHook struct {
+	mux   *sync.Mutex
+	spans map[trace.SpanID]int
+}
+
+func (h spanHook) record(ctx context.Context) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	h.spans[trace.SpanContextFromContext(ctx).SpanID()]++
+}
+
+func (spanHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h spanHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.record(ctx)
+		return next(ctx, cmd)
+	}
+}
+
+func (h spanHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		h.record(ctx)
+		return next(ctx, cmds)
+	}
+}
+
+func TestTelemetry(t *testing.T) {
+	defer reinit()
+
+	tracer := new(testTracer)
+	meter := &testMeter{durations: make(map[string]int)}
+
+	err := InitWithOptions(&redis.Options{Addr: "localhost:6379"}, &InitOptions{
+		Telemetry: &TelemetryOptions{
+			TracerProvider: tracer,
+			MeterProvider:  testMeterProvider{meter: meter},
+		},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hook := spanHook{mux: new(sync.Mutex), spans: make(map[trace.SpanID]int)}
+	C.AddHook(testHook{})
+	C.AddHook(hook)
+
+	owner := &TelemetryTestOwner{Name: "owner"}
+	supplier := &TelemetryTestSupplier{Name: "supplier", Owner: owner}
+	m := &TelemetryTestModel{Name: "apple", Supplier: supplier}
+
+	if err := StoreWithOptions(m, &StoreOptions{ID: NewID(), Cascade: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := new(TelemetryTestModel)
+
+	if err := Load(m.ID, loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Update("missing", &TelemetryTestModel{Name: "pear"}); err == nil {
+		t.Fatal("TestTelemetry FAILED, expected update of missing object to fail")
+	}
+
+	byName := tracer.ended()
+
+	if len(byName["grocery.Store"]) != 1 || len(byName["grocery.Load"]) != 1 || len(byName["grocery.Update"]) != 1 {
+		t.Fatalf("TestTelemetry FAILED, expected Store, Load and Update spans, got %v", byName)
+	}
+
+	store := byName["grocery.Store"][0]
+
+	if fields := store.attrs["grocery.fields"]; fields.AsInt64() != 5 {
+		t.Errorf("TestTelemetry FAILED, expected 5 fields to be stored with cascaded objects, got %d", fields.AsInt64())
+	}
+
+	load := byName["grocery.Load"][0]
+
+	if model := load.attrs["grocery.model"]; model.AsString() != "telemetrytestmodel" {
+		t.Errorf("TestTelemetry FAILED, expected model telemetrytestmodel, got %s", model.AsString())
+	} else if id := load.attrs["grocery.id"]; id.AsString() != m.ID {
+		t.Errorf("TestTelemetry FAILED, expected id %s, got %s", m.ID, id.AsString())
+	} else if refs := load.attrs["grocery.references"]; refs.AsInt64() != 2 {
+		t.Errorf("TestTelemetry FAILED, expected 2 references to be loaded, got %d", refs.AsInt64())
+	} else if fields, ok := load.attrs["grocery.fields"]; !ok || fields.AsInt64() == 0 {
+		t.Errorf("TestTelemetry FAILED, expected field count, got %v", fields.Emit())
+	}
+
+	// Each level of references is loaded in a child span
+	refLoads := byName["grocery.LoadReferences"]
+
+	if len(refLoads) != 2 {
+		t.Fatalf("TestTelemetry FAILED, expected 2 reference loads, got %d", len(refLoads))
+	}
+
+	for _, span := range refLoads {
+		if span.parent != load.sc.SpanID() {
+			t.Errorf("TestTelemetry FAILED, expected reference load to be a child of Load")
+		}
+	}
+
+	// Redis commands are run within grocery's spans
+	hook.mux.Lock()
+
+	for _, span := range []*testSpan{store, load, refLoads[0], refLoads[1]} {
+		if hook.spans[span.sc.SpanID()] == 0 {
+			t.Errorf("TestTelemetry FAILED, expected Redis commands within %s", span.name)
+		}
+	}
+
+	hook.mux.Unlock()
+
+	if update := byName["grocery.Update"][0]; update.status != codes.Error {
+		t.Errorf("TestTelemetry FAILED, expected failed update to have error status, got %s", update.status)
+	}
+
+	meter.mux.Lock()
+	counts, failures := meter.durations, meter.failures
+	meter.mux.Unlock()
+
+	if counts["grocery.Store"] != 1 || counts["grocery.Load"] != 1 || counts["grocery.LoadReferences"] != 2 {
+		t.Errorf("TestTelemetry FAILED, expected operation durations to be recorded, got %v", counts)
+	} else if failures != 1 {
+		t.Errorf("TestTelemetry FAILED, expected 1 failed operation, got %d", failures)
+	}
+}
+
+func TestTelemetryBatches(t *testing.T) {
+	defer reinit()
+
+	tracer := new(testTracer)
+
+	err := InitWithOptions(&redis.Options{Addr: "localhost:6379"}, &InitOptions{
+		Telemetry: &TelemetryOptions{
+			TracerProvider: tracer,
+			MeterProvider:  testMeterProvider{meter: &testMeter{durations: make(map[string]int)}},
+		},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hook := spanHook{mux: new(sync.Mutex), spans: make(map[trace.SpanID]int)}
+	C.AddHook(testHook{})
+	C.AddHook(hook)
+
+	supplier := &TelemetryTestSupplier{Name: "supplier"}
+	Store(supplier)
+
+	ids, err := StoreAll([]*TelemetryTestModel{{Name: "a", Supplier: supplier}, {Name: "b", Supplier: supplier}}, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateAll(ids, []*TelemetryTestModel{{Name: "c"}, {Name: "d"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadReferrers[TelemetryTestModel]("supplier", supplier.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	byName := tracer.ended()
+
+	for name, fields := range map[string]int64{"grocery.StoreAll": 4, "grocery.UpdateAll": 2, "grocery.LoadReferrers": -1} {
+		if len(byName[name]) != 1 {
+			t.Errorf("TestTelemetryBatches FAILED, expected a %s span, got %d", name, len(byName[name]))
+			continue
+		}
+
+		span := byName[name][0]
+
+		if model := span.attrs["grocery.model"]; model.AsString() != "telemetrytestmodel" {
+			t.Errorf("TestTelemetryBatches FAILED, expected model telemetrytestmodel for %s, got %s", name, model.AsString())
+		} else if n := span.attrs["grocery.fields"]; fields >= 0 && n.AsInt64() != fields {
+			t.Errorf("TestTelemetryBatches FAILED, expected %d fields for %s, got %d", fields, name, n.AsInt64())
+		}
+
+		hook.mux.Lock()
+
+		if hook.spans[span.sc.SpanID()] == 0 {
+			t.Errorf("TestTelemetryBatches FAILED, expected Redis commands within %s", name)
+		}
+
+		hook.mux.Unlock()
+	}
+
+	// The referrers are loaded by a child LoadEach
+	if referrers := byName["grocery.LoadReferrers"]; len(referrers) == 1 {
+		if len(byName["grocery.LoadEach"]) != 1 || byName["grocery.LoadEach"][0].parent != referrers[0].sc.SpanID() {
+			t.Errorf("TestTelemetryBatches FAILED, expected LoadEach to be a child of LoadReferrers")
+		}
+	}
+}
+
+func TestTelemetryInitFails(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln.Close()
+	tracer := new(testTracer)
+
+	err = InitWithOptions(&redis.Options{Addr: ln.Addr().String(), MaxRetries: -1}, &InitOptions{
+		Telemetry: &TelemetryOptions{TracerProvider: tracer},
+	})
+
+	if err == nil {
+		t.Fatal("TestTelemetryInitFails FAILED, expected Init to fail")
+	}
+
+	// The previous client is kept, without telemetry
+	if _, err := Store(&TelemetryTestOwner{Name: "owner"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if spans := tracer.ended(); len(spans) != 0 {
+		t.Errorf("TestTelemetryInitFails FAILED, expected no spans, got %v", spans)
+	}
+}
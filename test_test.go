@@ -4,6 +4,8 @@ import (
 	"context"
 	"net"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/redis/go-redis/v9"
@@ -11,6 +13,12 @@ import (
 
 var keys = map[string]bool{}
 
+// Guards keys, since handlers may run commands alongside tests.
+var keysMux sync.Mutex
+
+// Number of round trips made to Redis while running unit tests.
+var roundTrips int64
+
 func TestMain(m *testing.M) {
 	// Create grocery client
 	Init(&redis.Options{
@@ -31,6 +39,7 @@ func TestMain(m *testing.M) {
 	}
 
 	pip.Exec(ctx)
+	Close(ctx)
 
 	// Exit from test
 	os.Exit(code)
@@ -47,21 +56,34 @@ func (testHook) DialHook(next redis.DialHook) redis.DialHook {
 
 func (testHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
 	return func(ctx context.Context, cmd redis.Cmder) error {
+		atomic.AddInt64(&roundTrips, 1)
+
 		if err := next(ctx, cmd); err != nil {
 			return err
 		}
 
-		keys[cmd.Args()[1].(string)] = true
+		keysMux.Lock()
+		defer keysMux.Unlock()
+
+		if len(cmd.Args()) >= 2 {
+			keys[cmd.Args()[1].(string)] = true
+		}
+
 		return nil
 	}
 }
 
 func (testHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
 	return func(ctx context.Context, cmds []redis.Cmder) error {
+		atomic.AddInt64(&roundTrips, 1)
+
 		if err := next(ctx, cmds); err != nil {
 			return err
 		}
 
+		keysMux.Lock()
+		defer keysMux.Unlock()
+
 		for _, cmd := range cmds {
 			if len(cmd.Args()) >= 2 {
 				keys[cmd.Args()[1].(string)] = true
@@ -1,6 +1,7 @@
 package grocery
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -14,9 +15,14 @@ import (
 // the default behavior of Update needs to be changed.
 type UpdateOptions struct {
 	// Notify should be set to true if you would like a message to be published
-	// to the <struct name>:<id> channel once this update completes.
+	// to the <struct name>:<id> channel once this update completes. See
+	// Notification for its contents.
 	Notify bool
 
+	// NotifyValues should be set to true if you would like notifications to
+	// include the values that were written, in addition to their keys.
+	NotifyValues bool
+
 	// SetZeroValues should be set to true if you would like to update all zero
 	// values in Redis (e.g. empty strings, 0 ints). By default, when creating
 	// a struct to pass to Update, you may not set each value, which is why
@@ -24,12 +30,43 @@ type UpdateOptions struct {
 	// is not supported.
 	SetZeroValues bool
 
+	// Unset lists the grocery keys of fields that should be removed from
+	// Redis, so that they're loaded as zero values or nil. Hash fields are
+	// removed from the object's hash, and maps, sets and lists are deleted.
+	// Fields in Unset aren't written, even if they're set on the object.
+	Unset []string
+
 	// If you would like to run this store/update alongside other Redis
-	// updates, you may specify a pipeline.
+	// updates, you may specify a pipeline. The write is added to it as a
+	// script, which checks again that the object doesn't exist on a store,
+	// or exists on an update, when the pipeline is executed.
+	//
+	// The script only declares the object's key, but also writes its maps,
+	// sets and lists, backrefs, referenced objects and any commands added by
+	// hooks. Pipeline is therefore only supported with a single Redis node,
+	// not Redis Cluster, and an ACL user running it needs access to all of
+	// those keys.
 	Pipeline redis.Pipeliner
 
 	isStore        bool
 	storeOverwrite bool
+	storeReplace   bool
+
+	// Whether the object existed before it was stored.
+	existed bool
+
+	// The createdAt timestamp to store the object with, if it was given.
+	createdAt time.Time
+
+	// Whether referenced objects that haven't been stored yet should be
+	// stored, and the IDs of every object stored so far while cascading.
+	cascade  bool
+	cascaded map[interface{}]string
+
+	// Context of the operation this write is part of, and the number of
+	// fields it has written, including those of cascaded objects.
+	ctx     context.Context
+	written int
 }
 
 // Update updates an object with a given ID. By default, only non-zero values
@@ -49,194 +86,509 @@ type UpdateOptions struct {
 //	itemID := "asdf"
 //	db.Update(itemID, item)
 func Update(id string, ptr interface{}) error {
-	return updateInternal(id, ptr, &UpdateOptions{})
+	return UpdateWithOptions(id, ptr, &UpdateOptions{})
 }
 
 // UpdateWithOptions updates an object in Redis, like Update, but with options.
 func UpdateWithOptions(id string, ptr interface{}, opts *UpdateOptions) error {
-	return updateInternal(id, ptr, opts)
+	opts = copyOptions(opts)
+	op := startOperation(nil, "grocery.Update", prefixOf(ptr), id)
+	opts.ctx = op.ctx
+
+	err := updateInternal(id, ptr, opts)
+	op.setFields(opts.written)
+
+	return op.end(err)
+}
+
+// copyOptions returns a copy of opts, or empty options if it's nil, so that
+// the state of a write can be kept on its options without changing the
+// caller's, which may be shared with other writes.
+func copyOptions(opts *UpdateOptions) *UpdateOptions {
+	if opts == nil {
+		return &UpdateOptions{}
+	}
+
+	update := *opts
+	return &update
 }
 
 func updateInternal(id string, ptr interface{}, opts *UpdateOptions) error {
+	opts.written = 0
+
 	if id == "" {
 		return errors.New("ID must not be empty")
 	}
 
-	var val reflect.Value
-	var typ reflect.Type
+	val, typ, err := structOf(ptr)
+
+	if err != nil {
+		return err
+	}
+
+	if err := register(typ); err != nil {
+		return err
+	}
+
+	// Get prefix for the struct (e.g. 'answer:' from Answer)
+	prefix := strings.ToLower(typ.Name())
+
+	return writeChecked(prefix, id, ptr, typ, opts, func(pip redis.Pipeliner, old *stored) error {
+		opts.existed = old.exists
+
+		// Count the fields written by this attempt only
+		opts.written = 0
 
+		if opts.storeReplace {
+			queueReplace(pip, prefix, id, val, typ, old)
+		}
+
+		return queueUpdate(pip, prefix, id, ptr, val, typ, opts, old.refs)
+	})
+}
+
+// structOf returns the struct value and type of ptr, which may be either a
+// struct or a pointer to one.
+func structOf(ptr interface{}) (reflect.Value, reflect.Type, error) {
 	switch reflect.TypeOf(ptr).Kind() {
 	case reflect.Ptr:
 		if reflect.TypeOf(ptr).Elem().Kind() != reflect.Struct {
-			return errors.New("ptr must be a struct pointer")
+			return reflect.Value{}, nil, errors.New("ptr must be a struct pointer")
 		}
 
-		val = reflect.ValueOf(ptr).Elem()
-		typ = reflect.TypeOf(ptr).Elem()
+		return reflect.ValueOf(ptr).Elem(), reflect.TypeOf(ptr).Elem(), nil
 	case reflect.Struct:
-		val = reflect.ValueOf(ptr)
-		typ = reflect.TypeOf(ptr)
+		return reflect.ValueOf(ptr), reflect.TypeOf(ptr), nil
 	default:
-		return errors.New("ptr must be a struct pointer")
+		return reflect.Value{}, nil, errors.New("ptr must be a struct pointer")
 	}
+}
 
-	// Get prefix for the struct (e.g. 'answer:' from Answer)
-	prefix := strings.ToLower(typ.Name())
+// prefixOf returns the prefix of the struct type of ptr (e.g. 'answer' from
+// Answer), or an empty string if ptr isn't a struct or a pointer to one.
+func prefixOf(ptr interface{}) string {
+	if _, typ, err := structOf(ptr); err == nil {
+		return strings.ToLower(typ.Name())
+	}
 
-	// Make sure the object exists on an update, or not on a store
-	exists, _ := C.Exists(ctx, prefix+":"+id).Result()
+	return ""
+}
 
-	if opts.isStore && exists == 1 && !opts.storeOverwrite {
+// checkExists returns an error if an object must not exist because it's being
+// stored, or must exist because it's being updated.
+func checkExists(prefix, id string, exists bool, opts *UpdateOptions) error {
+	if opts.isStore && exists && !opts.storeOverwrite {
 		return fmt.Errorf("%s:%s already exists", prefix, id)
-	} else if !opts.isStore && exists == 0 {
+	} else if !opts.isStore && !exists {
 		return fmt.Errorf("%s:%s does not exist", prefix, id)
 	}
 
-	pip := opts.Pipeline
+	return nil
+}
 
-	if opts.Pipeline == nil {
-		pip = C.TxPipeline()
+// creating returns whether a write creates a new object, rather than updating
+// an existing one, which upserts and replacements do if the object exists.
+func creating(opts *UpdateOptions) bool {
+	return opts.isStore && !opts.existed
+}
+
+// queueUpdate adds the commands needed to store or update the object in val
+// to pip, without executing them. oldRefs holds the IDs the object currently
+// references through its backref fields, from queueBackrefReads.
+func queueUpdate(pip redis.Pipeliner, prefix, id string, ptr interface{}, val reflect.Value, typ reflect.Type, opts *UpdateOptions, oldRefs map[string][]string) error {
+	if err := callPreWriteHook(ptr, creating(opts)); err != nil {
+		return err
 	}
 
-	for i := 0; i < typ.NumField(); i++ {
-		typeField := typ.Field(i)
-		structField := val.Field(i)
+	if err := validate(ptr, val, typ, opts); err != nil {
+		return err
+	}
 
-		tagName := typeField.Tag.Get("grocery")
-		k := tagName
+	if opts.cascade && reflect.TypeOf(ptr).Kind() == reflect.Ptr {
+		// Let referenced objects that point back to this one use its ID
+		opts.cascaded[ptr] = id
+	}
 
-		if tagName == "-" {
-			continue
-		} else if structField.Kind() == reflect.Struct && typeField.Anonymous {
-			// Skip embedded structs
-			continue
-		} else if strings.Contains(tagName, ",") {
-			tagParts := strings.Split(tagName, ",")
-			k = tagParts[0]
-			tagInfo := tagParts[1]
+	unset, err := unsetFields(typ, opts.Unset)
 
-			if tagInfo == "immutable" {
-				continue
-			}
-		} else if tagName == "" {
-			// Tag was not specified, assume field name
-			if len(typeField.Name) > 1 {
-				k = strings.ToLower(string(typeField.Name[0])) + string(typeField.Name[1:])
-			} else {
-				k = strings.ToLower(typeField.Name)
+	if err != nil {
+		return err
+	}
+
+	fields := schemaOf(typ).fields
+	written := newChanges(opts)
+
+	if schemaOf(typ).marshaler && val.CanAddr() {
+		// Let the model write its own fields
+		if err := val.Addr().Interface().(Marshaler).MarshalGrocery(ctx, pip, prefix+":"+id, opts.SetZeroValues); err != nil {
+			return err
+		}
+
+		for _, f := range fields {
+			if (f.kind == kindValue || f.kind == kindTime) && !f.immutable && (opts.SetZeroValues || !val.Field(f.index).IsZero()) {
+				written.addField(f, val.Field(f.index))
+				opts.written++
 			}
 		}
 
+		fields = nil
+	}
+
+	for _, f := range fields {
+		structField := val.Field(f.index)
+		k := f.key
+
+		if f.kind == kindEmbedded || f.immutable || unset[k] != nil {
+			// Skip embedded structs, fields only grocery may write, and
+			// fields being removed
+			continue
+		}
+
 		if !opts.SetZeroValues && structField.IsZero() {
 			continue
 		}
 
-		switch typeField.Type.Kind() {
-		case reflect.Ptr:
+		switch f.kind {
+		case kindMap:
 			if structField.IsNil() {
 				continue
 			}
 
-			if typeField.Type == mapType || structField.Elem().FieldByName("CustomMapType").IsValid() {
-				pip.Del(ctx, prefix+":"+id+":"+k)
+			pip.Del(ctx, prefix+":"+id+":"+k)
 
-				structField.MethodByName("Range").Call([]reflect.Value{
-					reflect.ValueOf(func(key, value interface{}) bool {
-						pip.HSet(ctx, prefix+":"+id+":"+k, key, value)
-						return true
-					}),
-				})
-			} else if typeField.Type == setType || structField.Elem().FieldByName("CustomSetType").IsValid() {
-				pip.Del(ctx, prefix+":"+id+":"+k)
+			structField.Interface().(mapStore).Range(func(key, value interface{}) bool {
+				pip.HSet(ctx, prefix+":"+id+":"+k, key, value)
+				return true
+			})
 
-				structField.MethodByName("Range").Call([]reflect.Value{
-					reflect.ValueOf(func(key, value interface{}) bool {
-						pip.SAdd(ctx, prefix+":"+id+":"+k, key)
-						return true
-					}),
-				})
-			} else if !structField.Elem().FieldByName("Base").IsZero() {
-				val := structField.Elem().FieldByName("Base").FieldByName("ID").String()
-				pip.HSet(ctx, prefix+":"+id, k, val)
+			written.addField(f, structField)
+		case kindSet:
+			if structField.IsNil() {
+				continue
+			}
+
+			pip.Del(ctx, prefix+":"+id+":"+k)
+
+			structField.Interface().(setStore).Range(func(key, value interface{}) bool {
+				pip.SAdd(ctx, prefix+":"+id+":"+k, key)
+				return true
+			})
+
+			written.addField(f, structField)
+		case kindRef:
+			if structField.IsNil() {
+				continue
+			}
+
+			if refID, ok, err := referenceID(pip, structField, opts); err != nil {
+				return err
+			} else if ok {
+				pip.HSet(ctx, prefix+":"+id, k, refID)
+				written.add(k, refID)
+
+				if f.backref {
+					queueBackrefMove(pip, f.refPrefix, prefix, id, oldRefs[k], []string{refID})
+				}
 			} else {
-				return fmt.Errorf("can't set unknown field '%s'", tagName)
+				return fmt.Errorf("can't set unknown field '%s'", k)
 			}
-		case reflect.Slice:
+		case kindRefList:
 			// Delete old list before adding new entries
 			pip.Del(ctx, prefix+":"+id+":"+k)
+			itemIDs := make([]string, 0, structField.Len())
 
 			for i := 0; i < structField.Len(); i++ {
-				if !structField.Index(i).Elem().FieldByName("Base").IsZero() {
-					itemID := structField.Index(i).Elem().FieldByName("Base").FieldByName("ID").String()
+				if itemID, ok, err := referenceID(pip, structField.Index(i), opts); err != nil {
+					return err
+				} else if ok {
 					pip.RPush(ctx, prefix+":"+id+":"+k, itemID)
+					itemIDs = append(itemIDs, itemID)
 				} else {
-					return fmt.Errorf("can't set unknown array item in %s", tagName)
+					return fmt.Errorf("can't set unknown array item in %s", k)
 				}
 			}
-		case reflect.Map:
-			return fmt.Errorf("type of field '%s' must be changed to *grocery.Map", tagName)
-		case reflect.Struct:
-			switch structField.Type() {
-			case reflect.TypeOf(time.Now()):
-				timeVal := structField.MethodByName("Unix").Call([]reflect.Value{})[0].Int()
-				pip.HSet(ctx, prefix+":"+id, k, timeVal)
-			default:
-				return fmt.Errorf("can't set unknown struct for field '%s'", tagName)
+
+			if f.backref {
+				queueBackrefMove(pip, f.refPrefix, prefix, id, oldRefs[k], itemIDs)
 			}
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			// Handle int alias types
-			val := structField.Convert(reflect.TypeOf(0)).Int()
-			pip.HSet(ctx, prefix+":"+id, k, val)
-		case reflect.String:
-			// Handle string alias types
-			val := structField.Convert(reflect.TypeOf("")).String()
-			pip.HSet(ctx, prefix+":"+id, k, val)
-		case reflect.Bool:
-			if loadFunc := structField.MethodByName("Load"); loadFunc.IsValid() {
-				// Skip custom boolean values; they don't get stored
-				continue
-			} else {
-				pip.HSet(ctx, prefix+":"+id, k, structField.Bool())
+
+			written.add(k, itemIDs)
+		case kindStringList:
+			// Delete old list before adding new entries
+			pip.Del(ctx, prefix+":"+id+":"+k)
+
+			for i := 0; i < structField.Len(); i++ {
+				pip.RPush(ctx, prefix+":"+id+":"+k, structField.Index(i).String())
 			}
-		case reflect.Float64, reflect.Float32:
-			val := structField.Float()
-			pip.HSet(ctx, prefix+":"+id, k, val)
-		case reflect.Interface:
-			if structField.Type().Name() == "ModelHook" {
-				// Skip ModelHook fields
+
+			written.addField(f, structField)
+		case kindTime:
+			pip.HSet(ctx, prefix+":"+id, k, structField.Interface().(time.Time).Unix())
+			written.addField(f, structField)
+		case kindValue:
+			pip.HSet(ctx, prefix+":"+id, k, encodeValue(structField))
+			written.addField(f, structField)
+		case kindCustomBool, kindHook:
+			// Skip custom boolean values and ModelHook fields; they don't get
+			// stored
+			continue
+		default:
+			if structField.Kind() == reflect.Ptr && structField.IsNil() {
 				continue
+			} else if f.typ.Kind() == reflect.Slice {
+				// Delete old list before failing on its first item
+				pip.Del(ctx, prefix+":"+id+":"+k)
+
+				if structField.Len() == 0 {
+					continue
+				}
 			}
 
-			return fmt.Errorf("don't know how to set interface field '%s'", k)
-		default:
-			return fmt.Errorf("don't know how to set field '%s'", k)
+			return fmt.Errorf(f.writeErr, k)
 		}
+
+		opts.written++
 	}
 
+	queueUnset(pip, prefix, id, unset, opts, oldRefs, written)
+
 	// Set updatedAt timestamp
-	pip.HSet(ctx, prefix+":"+id, "updatedAt", time.Now().Unix())
+	stamp := now().Unix()
+	pip.HSet(ctx, prefix+":"+id, "updatedAt", stamp)
 
 	if opts.isStore {
-		// Set createdAt timestamp
-		pip.HSet(ctx, prefix+":"+id, "createdAt", time.Now().Unix())
-
-		// Call hook after calling store, if the object has one
-		if hook, ok := ptr.(ModelHook); ok {
-			hook.PostStore(pip)
+		// Set createdAt timestamp, keeping the original one if the object
+		// may already exist, unless one was given
+		if !opts.createdAt.IsZero() {
+			pip.HSet(ctx, prefix+":"+id, "createdAt", opts.createdAt.Unix())
+		} else if opts.storeOverwrite {
+			pip.HSetNX(ctx, prefix+":"+id, "createdAt", stamp)
+		} else {
+			pip.HSet(ctx, prefix+":"+id, "createdAt", stamp)
 		}
 	}
 
+	// Call hook after calling store or update, if the object has one
+	if hook, ok := ptr.(ModelHook); ok && creating(opts) {
+		hook.PostStore(pip)
+	} else if hook, ok := ptr.(PostUpdateHook); ok && !creating(opts) {
+		hook.PostUpdate(pip)
+	}
+
 	if opts.Notify {
 		// Publish message if notify is enabled
-		pip.Publish(ctx, prefix+":"+id, "")
+		event := EventUpdated
+
+		if creating(opts) {
+			event = EventCreated
+		}
+
+		queueNotify(pip, prefix, id, event, written)
 	}
 
-	// Don't exec if a pipeline was provided to us
-	if opts.Pipeline == nil {
-		if _, err := pip.Exec(ctx); err != nil {
-			return err
+	return nil
+}
+
+// queueUnset adds the commands that remove the fields in opts.Unset, from
+// unsetFields, to pip.
+func queueUnset(pip redis.Pipeliner, prefix, id string, unset map[string]*field, opts *UpdateOptions, oldRefs map[string][]string, written *changes) {
+	for _, k := range opts.Unset {
+		f := unset[k]
+
+		if f.kind == kindMap || f.kind == kindSet || f.typ.Kind() == reflect.Slice {
+			pip.Del(ctx, prefix+":"+id+":"+k)
+		} else {
+			pip.HDel(ctx, prefix+":"+id, k)
+		}
+
+		if f.backref {
+			queueBackrefMove(pip, f.refPrefix, prefix, id, oldRefs[k], nil)
+		}
+
+		written.add(k, nil)
+		opts.written++
+	}
+}
+
+// unsetFields returns the fields of typ with the given grocery keys, keyed by
+// their keys. It fails if any of them can't be removed.
+func unsetFields(typ reflect.Type, keys []string) (map[string]*field, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	fields := make(map[string]*field, len(keys))
+
+	for _, k := range keys {
+		fields[k] = fieldByKey(typ, k)
+
+		if fields[k] == nil {
+			return nil, fmt.Errorf("can't unset unknown field '%s'", k)
+		} else if fields[k].immutable {
+			return nil, fmt.Errorf("can't unset immutable field '%s'", k)
+		}
+	}
+
+	return fields, nil
+}
+
+// fieldByKey returns the field of typ with the given grocery key, including
+// the fields of embedded structs such as Base, or nil if there isn't one.
+func fieldByKey(typ reflect.Type, k string) *field {
+	for _, f := range schemaOf(typ).fields {
+		if f.kind == kindEmbedded {
+			if embedded := fieldByKey(f.typ, k); embedded != nil {
+				return embedded
+			}
+		} else if f.key == k {
+			return f
 		}
 	}
 
 	return nil
 }
+
+// Unset removes the fields with the given grocery keys from the object with
+// the given ID, so that they're loaded as zero values or nil. As with Delete,
+// ptr is a pointer to a struct of the object's type, which is only used to
+// determine its prefix and fields:
+//
+//	// Removes the item's price, and deletes its tags
+//	db.Unset(itemID, new(Item), "cost", "tags")
+//
+// Unlike Update, the object isn't validated, and its hooks aren't called,
+// since none of its other fields are written. Required fields can't be
+// removed. See UpdateOptions.Unset for removing fields while updating others.
+func Unset(id string, ptr interface{}, keys ...string) error {
+	_, typ, err := structOf(ptr)
+
+	if err != nil {
+		return err
+	}
+
+	// Get prefix for the struct (e.g. 'answer:' from Answer)
+	prefix := strings.ToLower(typ.Name())
+
+	opts := &UpdateOptions{Unset: keys}
+	op := startOperation(nil, "grocery.Unset", prefix, id)
+	opts.ctx = op.ctx
+
+	err = unsetInternal(prefix, id, ptr, typ, opts)
+	op.setFields(opts.written)
+
+	return op.end(err)
+}
+
+func unsetInternal(prefix, id string, ptr interface{}, typ reflect.Type, opts *UpdateOptions) error {
+	if id == "" {
+		return errors.New("ID must not be empty")
+	}
+
+	if err := register(typ); err != nil {
+		return err
+	}
+
+	unset, err := unsetFields(typ, opts.Unset)
+
+	if err != nil {
+		return err
+	}
+
+	verr := &ValidationError{Fields: make(map[string][]string)}
+
+	for k, f := range unset {
+		if hasOption(f.opts, "required") {
+			verr.Fields[k] = append(verr.Fields[k], "is required")
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+
+	return writeChecked(prefix, id, ptr, typ, opts, func(pip redis.Pipeliner, old *stored) error {
+		// Count the fields removed by this attempt only
+		opts.written = 0
+
+		queueUnset(pip, prefix, id, unset, opts, old.refs, nil)
+		pip.HSet(ctx, prefix+":"+id, "updatedAt", now().Unix())
+		return nil
+	})
+}
+
+// queueReplace adds commands to pip that remove the fields and sub-keys of
+// the object stored at prefix:id, so that only the fields of the object in
+// val are left once it's written. createdAt and other immutable fields are
+// kept. old holds what's currently stored for the object.
+func queueReplace(pip redis.Pipeliner, prefix, id string, val reflect.Value, typ reflect.Type, old *stored) {
+	s := schemaOf(typ)
+	keep := map[string]bool{"createdAt": true}
+
+	for _, f := range s.fields {
+		if f.immutable {
+			keep[f.key] = true
+		}
+	}
+
+	var stale []string
+
+	for _, k := range old.fields {
+		if !keep[k] {
+			stale = append(stale, k)
+		}
+	}
+
+	if len(stale) > 0 {
+		pip.HDel(ctx, prefix+":"+id, stale...)
+	}
+
+	for _, k := range s.subKeys {
+		pip.Del(ctx, prefix+":"+id+":"+k)
+	}
+
+	// Remove the object from the backref sets of references that are unset
+	for _, f := range s.fields {
+		if f.backref && val.Field(f.index).IsZero() {
+			queueBackrefMove(pip, f.refPrefix, prefix, id, old.refs[f.key], nil)
+		}
+	}
+}
+
+// referenceID returns the ID of the model referenced by ref, a pointer to a
+// struct that embeds Base. If the model hasn't been stored yet and cascading
+// is enabled, the commands to store it are added to pip, and its new ID is
+// set on it. ok is false if the model has no ID.
+func referenceID(pip redis.Pipeliner, ref reflect.Value, opts *UpdateOptions) (id string, ok bool, err error) {
+	if ref.IsNil() {
+		return "", false, nil
+	} else if base := ref.Elem().FieldByName("Base"); !base.IsZero() {
+		return base.FieldByName("ID").String(), true, nil
+	} else if !opts.cascade {
+		return "", false, nil
+	} else if id, ok := opts.cascaded[ref.Interface()]; ok {
+		// The model is already being stored
+		return id, true, nil
+	}
+
+	id = NewID()
+	refOpts := &UpdateOptions{
+		Notify:   opts.Notify,
+		isStore:  true,
+		cascade:  true,
+		cascaded: opts.cascaded,
+	}
+
+	prefix := strings.ToLower(ref.Elem().Type().Name())
+
+	if err := register(ref.Elem().Type()); err != nil {
+		return "", false, err
+	}
+
+	if err := queueUpdate(pip, prefix, id, ref.Interface(), ref.Elem(), ref.Elem().Type(), refOpts, nil); err != nil {
+		return "", false, err
+	}
+
+	opts.written += refOpts.written
+	setID(ref.Elem(), id)
+	return id, true, nil
+}
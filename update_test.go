@@ -1,6 +1,8 @@
 package grocery
 
 import (
+	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -86,3 +88,97 @@ func TestSetTimeValue(t *testing.T) {
 		t.Errorf("TestSetTimeValue FAILED, initial value was not set correctly")
 	}
 }
+
+type UnsetTestModel struct {
+	Base
+
+	Name  string   `grocery:"name"`
+	Price float64  `grocery:"price"`
+	Tags  []string `grocery:"tags"`
+	Meta  *Map     `grocery:"meta"`
+	Code  string   `grocery:"code,required"`
+}
+
+// Validate fails for the empty objects that the standalone Unset must not
+// validate.
+func (m *UnsetTestModel) Validate() error {
+	if m.Name == "" {
+		return errors.New("name is missing")
+	}
+
+	return nil
+}
+
+func (m *UnsetTestModel) PreUpdate() error {
+	return m.Validate()
+}
+
+func TestUnset(t *testing.T) {
+	id, err := Store(&UnsetTestModel{
+		Name:  "a",
+		Price: 1,
+		Tags:  []string{"x"},
+		Meta:  NewMap(map[string]string{"k": "v"}),
+		Code:  "c",
+	})
+
+	if err != nil {
+		t.Fatalf("TestUnset FAILED, got error %v", err)
+	}
+
+	err = UpdateWithOptions(id, &UnsetTestModel{Name: "b", Price: 2}, &UpdateOptions{Unset: []string{"price"}})
+
+	if err != nil {
+		t.Fatalf("TestUnset FAILED, got error %v", err)
+	}
+
+	if err := Unset(id, new(UnsetTestModel), "tags", "meta"); err != nil {
+		t.Fatalf("TestUnset FAILED, got error %v", err)
+	}
+
+	m := new(UnsetTestModel)
+	Load(id, m)
+
+	if m.Name != "b" || m.Price != 0 || len(m.Tags) != 0 || m.Meta.Count() != 0 {
+		t.Errorf("TestUnset FAILED, expected price, tags and meta to be removed but got %+v", m)
+	}
+
+	if C.HExists(ctx, "unsettestmodel:"+id, "price").Val() {
+		t.Errorf("TestUnset FAILED, expected price to be removed from the hash")
+	}
+
+	if err := Unset(id, new(UnsetTestModel), "code"); err == nil {
+		t.Errorf("TestUnset FAILED, expected error unsetting required field")
+	}
+
+	if err := Unset(id, new(UnsetTestModel), "unknown"); err == nil {
+		t.Errorf("TestUnset FAILED, expected error unsetting unknown field")
+	}
+
+	if err := Unset(id, new(UnsetTestModel), "createdAt"); err == nil || !strings.Contains(err.Error(), "immutable") {
+		t.Errorf("TestUnset FAILED, expected error unsetting immutable field but got %v", err)
+	}
+}
+
+func BenchmarkQueueUpdate(b *testing.B) {
+	m := &LoadTestModel{
+		StringVal:      "hello world",
+		IntVal:         4,
+		UInt16Val:      3,
+		BoolVal:        true,
+		Float32Val:     3.5,
+		Float64Val:     3.9,
+		StringAliasVal: "asdf",
+	}
+
+	val, typ, _ := structOf(m)
+	opts := &UpdateOptions{isStore: true}
+
+	for i := 0; i < b.N; i++ {
+		pip, _ := record()
+
+		if err := queueUpdate(pip, "loadtestmodel", "id", m, val, typ, opts, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package grocery
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validator may be implemented by models that need to check more than their
+// tags can express before they're stored or updated. See ValidationError.
+type Validator interface {
+	// Validate is called before any commands to store or update the object
+	// are passed to a pipeline. Returning an error aborts the write.
+	Validate() error
+}
+
+// ValidationError is returned by Store and Update, and the functions built on
+// them, when an object is invalid. Each field may declare rules in its
+// grocery tag, after its key:
+//
+//	type Fruit struct {
+//	    grocery.Base
+//	    Name  string  `grocery:"name,required,max=64"`
+//	    Price float64 `grocery:"cost,min=0"`
+//	    Color string  `grocery:"color,oneof=red green yellow"`
+//	    Code  string  `grocery:"code,len=6,regex=^[A-Z0-9]+$"`
+//	}
+//
+// required fails for zero values. min and max bound numbers by their value,
+// and strings, lists, maps and sets by their length, as does len. oneof lists
+// the allowed values separated by spaces, and regex must match strings. Since
+// rules are separated by commas, regex patterns can't contain commas.
+//
+// When updating without SetZeroValues, rules are only checked for fields that
+// are being written, since zero values are skipped.
+type ValidationError struct {
+	// The reasons each invalid field failed validation, keyed by the field's
+	// grocery key.
+	Fields map[string][]string
+
+	// The error returned by the object's Validate method, if any.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	reasons := make([]string, 0, len(keys)+1)
+
+	for _, k := range keys {
+		for _, reason := range e.Fields[k] {
+			reasons = append(reasons, k+" "+reason)
+		}
+	}
+
+	if e.Err != nil {
+		reasons = append(reasons, e.Err.Error())
+	}
+
+	return "validation failed: " + strings.Join(reasons, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// validate checks the fields of the object in val against the rules in their
+// tags, and then calls its Validate method, if it has one. It returns a
+// *ValidationError if the object is invalid.
+func validate(ptr interface{}, val reflect.Value, typ reflect.Type, opts *UpdateOptions) error {
+	verr := &ValidationError{Fields: make(map[string][]string)}
+
+	for _, f := range schemaOf(typ).fields {
+		structField := val.Field(f.index)
+
+		if f.anonymous || len(f.rules) == 0 {
+			continue
+		} else if hasOption(opts.Unset, f.key) {
+			// This field will be removed instead of written
+			if hasOption(f.opts, "required") {
+				verr.Fields[f.key] = append(verr.Fields[f.key], "is required")
+			}
+
+			continue
+		} else if !creating(opts) && !opts.storeReplace && !opts.SetZeroValues && structField.IsZero() {
+			// This field won't be written
+			continue
+		}
+
+		for _, r := range f.rules {
+			if reason := checkRule(structField, r); reason != "" {
+				verr.Fields[f.key] = append(verr.Fields[f.key], reason)
+			}
+		}
+	}
+
+	if validator, ok := ptr.(Validator); ok {
+		verr.Err = validator.Validate()
+	}
+
+	if len(verr.Fields) > 0 || verr.Err != nil {
+		return verr
+	}
+
+	return nil
+}
+
+// checkRule checks field against a single rule from its grocery tag, and
+// returns the reason it's invalid, or an empty string if it's valid.
+func checkRule(field reflect.Value, r rule) string {
+	if r.invalid != "" {
+		return r.invalid
+	} else if r.name == "required" {
+		if field.IsZero() {
+			return "is required"
+		}
+
+		return ""
+	}
+
+	// Check the values of pointers to primitives
+	for field.Kind() == reflect.Ptr && field.Type() != mapType && field.Type() != setType {
+		if field.IsNil() {
+			return ""
+		}
+
+		field = field.Elem()
+	}
+
+	switch r.name {
+	case "min", "max", "len":
+		size, isLength, ok := sizeOf(field)
+
+		if !ok {
+			return fmt.Sprintf("does not support the %s rule", r.name)
+		} else if r.name == "len" && !isLength {
+			return "does not support the len rule"
+		}
+
+		unit := ""
+
+		if isLength {
+			unit = "a length of "
+		}
+
+		if r.name == "min" && size < r.bound {
+			return fmt.Sprintf("must have %sat least %s", unit, r.arg)
+		} else if r.name == "max" && size > r.bound {
+			return fmt.Sprintf("must have %sat most %s", unit, r.arg)
+		} else if r.name == "len" && size != r.bound {
+			return fmt.Sprintf("must have a length of %s", r.arg)
+		}
+	case "oneof":
+		value := fmt.Sprint(field.Interface())
+
+		for _, allowed := range strings.Fields(r.arg) {
+			if value == allowed {
+				return ""
+			}
+		}
+
+		return fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(r.arg), ", "))
+	case "regex":
+		if field.Kind() != reflect.String {
+			return "does not support the regex rule"
+		} else if !r.re.MatchString(field.String()) {
+			return fmt.Sprintf("must match %s", r.arg)
+		}
+	}
+
+	return ""
+}
+
+// sizeOf returns the value of a number, or the length of a string, list, map
+// or set. isLength is false for numbers, and ok is false for other types.
+func sizeOf(field reflect.Value) (size float64, isLength bool, ok bool) {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(field.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(field.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return field.Float(), false, true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(field.String())), true, true
+	case reflect.Slice:
+		return float64(field.Len()), true, true
+	case reflect.Ptr:
+		if field.IsNil() {
+			return 0, true, true
+		} else if field.Type() == mapType {
+			return float64(field.Interface().(*Map).Count()), true, true
+		} else if field.Type() == setType {
+			return float64(field.Interface().(*Set).Cardinality()), true, true
+		}
+	}
+
+	return 0, false, false
+}
@@ -0,0 +1,133 @@
+package grocery
+
+import (
+	"errors"
+	"testing"
+)
+
+type ValidateTestModel struct {
+	Base
+	Name  string   `grocery:"name,required,max=8"`
+	Price float64  `grocery:"cost,min=0"`
+	Color string   `grocery:"color,oneof=red green"`
+	Code  string   `grocery:"code,len=3,regex=^[A-Z]+$"`
+	Tags  []string `grocery:"tags,max=2"`
+}
+
+func (m *ValidateTestModel) Validate() error {
+	if m.Color == "green" && m.Price > 10 {
+		return errors.New("green fruits are cheap")
+	}
+
+	return nil
+}
+
+func TestValidation(t *testing.T) {
+	model := &ValidateTestModel{
+		Price: -1,
+		Color: "blue",
+		Code:  "abcd",
+		Tags:  []string{"a", "b", "c"},
+	}
+
+	_, err := Store(model)
+
+	var verr *ValidationError
+
+	if !errors.As(err, &verr) {
+		t.Errorf("validation FAILED, expected a ValidationError but got %v", err)
+		return
+	}
+
+	for _, k := range []string{"name", "cost", "color", "code", "tags"} {
+		if len(verr.Fields[k]) == 0 {
+			t.Errorf("validation FAILED, expected %s to be invalid", k)
+		}
+	}
+
+	if len(verr.Fields["code"]) != 2 {
+		t.Errorf("validation FAILED, expected code to fail two rules but got %v", verr.Fields["code"])
+	}
+
+	id, err := Store(&ValidateTestModel{Name: "mango", Color: "red", Code: "ABC"})
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Fields that aren't written aren't validated
+	if err := Update(id, &ValidateTestModel{Price: 5}); err != nil {
+		t.Errorf("validation FAILED, got error %v", err)
+	}
+
+	err = Update(id, &ValidateTestModel{Color: "green", Price: 20})
+
+	if !errors.As(err, &verr) || verr.Err == nil {
+		t.Errorf("validation FAILED, expected Validate to fail but got %v", err)
+	}
+}
+
+func TestUpsertValidation(t *testing.T) {
+	id := NewID()
+
+	if err := StoreWithOptions(&ValidateTestModel{Price: 5}, &StoreOptions{ID: id, Upsert: true}); err == nil {
+		t.Errorf("validation FAILED, expected upsert of a new object to require name")
+	}
+
+	if err := StoreWithOptions(&ValidateTestModel{Name: "mango", Color: "red", Code: "ABC"}, &StoreOptions{ID: id}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Upserting an existing object only validates the fields it writes
+	if err := StoreWithOptions(&ValidateTestModel{Price: 5}, &StoreOptions{ID: id, Upsert: true}); err != nil {
+		t.Errorf("validation FAILED, got error %v", err)
+	}
+
+	// Replacing it validates every field, since the others are removed
+	if err := StoreWithOptions(&ValidateTestModel{Price: 5}, &StoreOptions{ID: id, Replace: true}); err == nil {
+		t.Errorf("validation FAILED, expected replace to require name")
+	}
+}
+
+func TestSaveValidation(t *testing.T) {
+	id, err := Store(&ValidateTestModel{Name: "mango", Color: "red", Code: "ABC"})
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	model := new(ValidateTestModel)
+
+	if err := Load(id, model); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Clearing a required field would remove it, so it fails validation
+	model.Name = ""
+
+	var verr *ValidationError
+
+	if err := Save(model); !errors.As(err, &verr) || len(verr.Fields["name"]) == 0 {
+		t.Errorf("validation FAILED, expected name to be required but got %v", err)
+	}
+
+	if name, _ := C.HGet(ctx, "validatetestmodel:"+id, "name").Result(); name != "mango" {
+		t.Errorf("validation FAILED, expected name to be kept but got %q", name)
+	}
+
+	// Clearing a field that isn't required removes it
+	model.Name = "mango"
+	model.Color = ""
+
+	if err := Save(model); err != nil {
+		t.Errorf("validation FAILED, got error %v", err)
+	}
+
+	if n, _ := C.HExists(ctx, "validatetestmodel:"+id, "color").Result(); n {
+		t.Errorf("validation FAILED, expected color to be removed")
+	}
+}
@@ -0,0 +1,141 @@
+package grocery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// WatchEvent is sent by Watch and WatchAll each time a watched object is
+// notified.
+type WatchEvent[T any] struct {
+	// EventCreated, EventUpdated or EventDeleted. Objects that no longer
+	// exist when they're reloaded are reported as deleted.
+	Event string
+
+	// The ID of the object.
+	ID string
+
+	// The object as it was loaded after the notification, or nil if it was
+	// deleted or couldn't be loaded.
+	Value *T
+
+	// Any error that occurred while loading the object.
+	Err error
+}
+
+// Watch returns a channel that receives an event each time one of the objects
+// of type T with the given IDs is notified, either by a write with
+// UpdateOptions.Notify or a delete with DeleteOptions.Notify. Each event
+// holds the object as it's reloaded from Redis:
+//
+//	events := db.Watch[Item](ctx, itemID)
+//
+//	for event := range events {
+//	    if event.Event == db.EventDeleted {
+//	        break
+//	    }
+//
+//	    fmt.Println(event.Value.Name)
+//	}
+//
+// The channel is closed, and the objects are no longer watched, once ctx is
+// done. Events for each object are delivered in the order the notifications
+// are received. While an event is waiting to be sent, messages handled by
+// the same worker wait too, so the channel should be read promptly. See
+// DispatchOptions.
+func Watch[T any](ctx context.Context, ids ...string) <-chan WatchEvent[T] {
+	prefix := watchPrefix[T]()
+	channels := make([]string, len(ids))
+
+	for i, id := range ids {
+		channels[i] = prefix + ":" + id
+	}
+
+	return watch[T](ctx, prefix, channels, nil)
+}
+
+// WatchAll returns a channel that receives an event each time any object of
+// type T is notified. See Watch for more information.
+func WatchAll[T any](ctx context.Context) <-chan WatchEvent[T] {
+	prefix := watchPrefix[T]()
+	return watch[T](ctx, prefix, nil, []string{prefix + ":*"})
+}
+
+// watchPrefix returns the prefix of T, registering it with grocery.
+func watchPrefix[T any]() string {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	register(typ)
+
+	return strings.ToLower(typ.Name())
+}
+
+// watch sends an event to the returned channel for each message published to
+// channels or patterns, until ctx is done.
+func watch[T any](ctx context.Context, prefix string, channels, patterns []string) <-chan WatchEvent[T] {
+	events := make(chan WatchEvent[T])
+
+	// Guards events, so that it isn't closed while an event is being sent
+	var mux sync.Mutex
+	closed := false
+
+	l := addListener(channels, patterns, func(channel string, payload []byte) {
+		id := strings.TrimPrefix(channel, prefix+":")
+
+		if id == "" || strings.Contains(id, ":") {
+			// Published to one of the object's other keys
+			return
+		}
+
+		event := watchEvent[T](id, payload)
+
+		mux.Lock()
+		defer mux.Unlock()
+
+		if closed {
+			return
+		}
+
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	})
+
+	go func() {
+		<-ctx.Done()
+		removeListener(l)
+
+		mux.Lock()
+		closed = true
+		close(events)
+		mux.Unlock()
+	}()
+
+	return events
+}
+
+// watchEvent returns the event for the object of type T with the given ID,
+// reloading it unless payload is a Notification of its deletion. Payloads
+// that aren't notifications are treated as updates.
+func watchEvent[T any](id string, payload []byte) WatchEvent[T] {
+	n := Notification{Event: EventUpdated}
+	json.Unmarshal(payload, &n)
+
+	if n.Event == EventDeleted {
+		return WatchEvent[T]{Event: EventDeleted, ID: id}
+	}
+
+	results, err := LoadEach[T]([]string{id}, nil)
+
+	if err != nil {
+		return WatchEvent[T]{Event: n.Event, ID: id, Err: err}
+	} else if errors.Is(results[0].Err, ErrNotFound) {
+		return WatchEvent[T]{Event: EventDeleted, ID: id}
+	}
+
+	return WatchEvent[T]{Event: n.Event, ID: id, Value: results[0].Value, Err: results[0].Err}
+}
@@ -0,0 +1,111 @@
+package grocery
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type WatchTestModel struct {
+	Base
+
+	Name string `grocery:"name"`
+}
+
+func receiveWatchEvent(t *testing.T, events <-chan WatchEvent[WatchTestModel]) WatchEvent[WatchTestModel] {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("TestWatch FAILED, expected an event")
+	}
+
+	return WatchEvent[WatchTestModel]{}
+}
+
+// receiveWatchEvents receives an event from both events and all, in whichever
+// order they're sent, since an unread event holds up the other one.
+func receiveWatchEvents(t *testing.T, events, all <-chan WatchEvent[WatchTestModel]) (WatchEvent[WatchTestModel], WatchEvent[WatchTestModel]) {
+	var event, allEvent WatchEvent[WatchTestModel]
+	timeout := time.After(time.Second)
+
+	for events != nil || all != nil {
+		select {
+		case event = <-events:
+			events = nil
+		case allEvent = <-all:
+			all = nil
+		case <-timeout:
+			t.Fatal("TestWatch FAILED, expected an event")
+		}
+	}
+
+	return event, allEvent
+}
+
+func TestWatch(t *testing.T) {
+	watchCtx, cancel := context.WithCancel(ctx)
+
+	id := NewID()
+	other := NewID()
+	events := Watch[WatchTestModel](watchCtx, id)
+	all := WatchAll[WatchTestModel](watchCtx)
+
+	if err := StoreWithOptions(&WatchTestModel{Name: "first"}, &StoreOptions{ID: id, UpdateOptions: &UpdateOptions{Notify: true}}); err != nil {
+		t.Fatal(err)
+	}
+
+	event, allEvent := receiveWatchEvents(t, events, all)
+
+	if event.Event != EventCreated || event.ID != id || event.Value == nil || event.Value.Name != "first" {
+		t.Errorf("TestWatch FAILED, expected created event with loaded object, got %+v", event)
+	} else if allEvent.ID != id {
+		t.Errorf("TestWatch FAILED, expected WatchAll to receive %s, got %+v", id, allEvent)
+	}
+
+	if err := StoreWithOptions(&WatchTestModel{Name: "other"}, &StoreOptions{ID: other, UpdateOptions: &UpdateOptions{Notify: true}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if event := receiveWatchEvent(t, all); event.ID != other || event.Value.Name != "other" {
+		t.Errorf("TestWatch FAILED, expected WatchAll to receive %s, got %+v", other, event)
+	}
+
+	if err := UpdateWithOptions(id, &WatchTestModel{Name: "second"}, &UpdateOptions{Notify: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if event, _ := receiveWatchEvents(t, events, all); event.Event != EventUpdated || event.Value.Name != "second" {
+		t.Errorf("TestWatch FAILED, expected updated event, got %+v", event)
+	}
+
+	if err := DeleteWithOptions(id, new(WatchTestModel), &DeleteOptions{Notify: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if event, _ := receiveWatchEvents(t, events, all); event.Event != EventDeleted || event.Value != nil {
+		t.Errorf("TestWatch FAILED, expected deleted event, got %+v", event)
+	}
+
+	cancel()
+
+	for _, ch := range []<-chan WatchEvent[WatchTestModel]{events, all} {
+		select {
+		case _, ok := <-ch:
+			if ok {
+				t.Errorf("TestWatch FAILED, expected no events once the context is done")
+			}
+		case <-time.After(time.Second):
+			t.Errorf("TestWatch FAILED, expected channel to be closed once the context is done")
+		}
+	}
+
+	handlersMux.RLock()
+	defer handlersMux.RUnlock()
+
+	if _, ok := handlers["watchtestmodel:"+id]; ok {
+		t.Errorf("TestWatch FAILED, expected listener to be removed")
+	} else if _, ok := patternHandlers["watchtestmodel:*"]; ok {
+		t.Errorf("TestWatch FAILED, expected pattern listener to be removed")
+	}
+}